@@ -0,0 +1,271 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/ncatelli/mockserver/pkg/config"
+	"github.com/ncatelli/mockserver/pkg/router/drivers"
+	"github.com/ncatelli/mockserver/pkg/router/drivers/loader"
+)
+
+// command describes a mockserver subcommand. Run is passed the arguments
+// following the command name and returns the process exit status.
+type command struct {
+	Summary string
+	Run     func(args []string, stdout, stderr io.Writer) int
+}
+
+var commands = map[string]command{
+	"serve": {
+		Summary: "serve the route configuration (default)",
+		Run:     serveCommand,
+	},
+	"validate": {
+		Summary: "check route configurations without serving them",
+		Run:     validateCommand,
+	},
+	"routes": {
+		Summary: "print the routes defined by the route configuration",
+		Run:     routesCommand,
+	},
+	"convert": {
+		Summary: "convert the route configuration to another driver's format",
+		Run:     convertCommand,
+	},
+}
+
+// run dispatches the arguments to a command, defaulting to serve when no
+// command is named so that mockserver can still be configured entirely
+// through the environment.
+func run(args []string, stdout, stderr io.Writer) int {
+	name := "serve"
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		name, args = args[0], args[1:]
+	}
+
+	if name == "help" {
+		usage(stdout)
+		return 0
+	}
+
+	cmd, prs := commands[name]
+	if !prs {
+		fmt.Fprintf(stderr, "unknown command %q\n\n", name)
+		usage(stderr)
+		return 2
+	}
+
+	return cmd.Run(args, stdout, stderr)
+}
+
+// usage writes the list of available commands to w.
+func usage(w io.Writer) {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintf(w, "Usage: mockserver [command] [flags]\n\nCommands:\n")
+	for _, name := range names {
+		fmt.Fprintf(w, "  %-10s %s\n", name, commands[name].Summary)
+	}
+	fmt.Fprintf(w, "\nRun 'mockserver <command> -h' for a command's flags.\n")
+}
+
+// parseCommand parses the configuration for a command from the environment
+// and then from args, so that flags override environment variables. Any
+// command specific flags are registered by register prior to parsing. The
+// remaining positional arguments are returned alongside the configuration.
+func parseCommand(name string, args []string, stderr io.Writer, register func(*flag.FlagSet, *config.Config)) (config.Config, []string, error) {
+	c, err := config.New()
+	if err != nil {
+		return c, nil, err
+	}
+
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	configFlags(fs, &c)
+	if register != nil {
+		register(fs, &c)
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return c, nil, err
+	}
+
+	return c, fs.Args(), nil
+}
+
+// configFlags registers the flags shared by every command for locating and
+// loading the route configuration. Defaults are taken from the environment.
+// Setting either the path or the url of the configuration clears the other,
+// so that a flag takes priority over the environment variable of either.
+func configFlags(fs *flag.FlagSet, c *config.Config) {
+	for _, name := range []string{"c", "config"} {
+		fs.Func(name, "path to a route configuration file, directory or glob pattern (CONFIG_PATH)", func(v string) error {
+			c.ConfigPath = v
+			c.ConfigURL = url.URL{}
+			return nil
+		})
+	}
+
+	for _, name := range []string{"u", "url"} {
+		fs.Func(name, "url to fetch the route configuration from (CONFIG_URL)", func(v string) error {
+			u, err := url.Parse(v)
+			if err != nil {
+				return err
+			}
+
+			c.ConfigPath = ""
+			c.ConfigURL = *u
+			return nil
+		})
+	}
+
+	fs.StringVar(&c.ConfigFormat, "format", c.ConfigFormat, "format of the route configuration (CONFIG_FORMAT)")
+	fs.StringVar(&c.ConfigDriver, "driver", c.ConfigDriver, "driver used to load the route configuration (CONFIG_DRIVER)")
+	fs.Func("driver-options", "comma separated key:value options passed to the driver (CONFIG_DRIVER_OPTIONS)", func(v string) error {
+		return c.DriverOptions.UnmarshalText([]byte(v))
+	})
+	fs.DurationVar(&c.ConfigTimeout, "timeout", c.ConfigTimeout, "timeout when fetching the route configuration from a url (CONFIG_TIMEOUT)")
+	fs.StringVar(&c.ResponseBaseDir, "response-base-dir", c.ResponseBaseDir, "directory relative response paths are resolved against (RESPONSE_BASE_DIR)")
+}
+
+// exitStatus returns the exit status for a failure to parse a command's
+// flags. Requesting help isn't considered a failure.
+func exitStatus(err error) int {
+	if errors.Is(err, flag.ErrHelp) {
+		return 0
+	}
+
+	return 2
+}
+
+func serveCommand(args []string, stdout, stderr io.Writer) int {
+	c, _, err := parseCommand("serve", args, stderr, func(fs *flag.FlagSet, c *config.Config) {
+		for _, name := range []string{"a", "addr"} {
+			fs.StringVar(&c.Addr, name, c.Addr, "address to listen on (ADDR)")
+		}
+
+		fs.DurationVar(&c.PollInterval, "poll-interval", c.PollInterval, "interval to poll the configuration url for changes on (CONFIG_POLL_INTERVAL)")
+		fs.StringVar(&c.StatePath, "state-path", c.StatePath, "path to persist scenario and resource state to (STATE_PATH)")
+	})
+	if err != nil {
+		return exitStatus(err)
+	}
+
+	serve(c)
+	return 0
+}
+
+func validateCommand(args []string, stdout, stderr io.Writer) int {
+	c, paths, err := parseCommand("validate", args, stderr, nil)
+	if err != nil {
+		return exitStatus(err)
+	}
+
+	return validate(c, paths, stdout)
+}
+
+func routesCommand(args []string, stdout, stderr io.Writer) int {
+	c, _, err := parseCommand("routes", args, stderr, nil)
+	if err != nil {
+		return exitStatus(err)
+	}
+
+	routes, _, err := buildRouterFromConfig(&c)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	defer func() {
+		for _, route := range routes {
+			route.Close()
+		}
+	}()
+
+	printRoutes(stdout, routes)
+	return 0
+}
+
+func convertCommand(args []string, stdout, stderr io.Writer) int {
+	var to, out string
+	c, _, err := parseCommand("convert", args, stderr, func(fs *flag.FlagSet, c *config.Config) {
+		fs.StringVar(&to, "to", "", "driver to convert the route configuration to (required)")
+		fs.StringVar(&out, "o", "", "file to write the converted configuration to (default stdout)")
+	})
+	if err != nil {
+		return exitStatus(err)
+	}
+
+	d := drivers.Lookup(to)
+	if d == nil {
+		fmt.Fprintln(stderr, drivers.ErrUndefinedDriver{ID: to})
+		return 2
+	}
+
+	enc, ok := d.(drivers.Encoder)
+	if !ok {
+		fmt.Fprintf(stderr, "driver %s does not support encoding routes\n", to)
+		return 2
+	}
+
+	entries, err := decodeEntries(&c)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+
+	w := stdout
+	if len(out) > 0 {
+		f, err := os.Create(out)
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return 1
+		}
+		defer f.Close()
+
+		w = f
+	}
+
+	if err := enc.Encode(w, entries); err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+
+	return 0
+}
+
+// decodeEntries reads the entries of the route configuration as written, so
+// that converting it leaves environment variable references, include
+// directives and relative paths intact.
+func decodeEntries(c *config.Config) ([]loader.Entry, error) {
+	d, err := driverForConfig(c)
+	if err != nil {
+		return nil, err
+	}
+
+	dec, ok := d.(drivers.Decoder)
+	if !ok {
+		return nil, fmt.Errorf("driver %s does not support decoding routes", driverName(c))
+	}
+
+	if len(c.ConfigPath) > 0 {
+		return dec.DecodeFromPath(c.ConfigPath)
+	}
+
+	data, err := c.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	return dec.Decode(data)
+}
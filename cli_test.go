@@ -0,0 +1,155 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestParseCommandShould(t *testing.T) {
+	os.Setenv("CONFIG_PATH", "from-env.yaml")
+	defer os.Unsetenv("CONFIG_PATH")
+
+	t.Run("default to the environment", func(t *testing.T) {
+		c, _, err := parseCommand("test", nil, &bytes.Buffer{}, nil)
+		if err != nil {
+			t.Fatalf(errFmt, nil, err)
+		}
+
+		if c.ConfigPath != "from-env.yaml" {
+			t.Errorf(errFmt, "from-env.yaml", c.ConfigPath)
+		}
+	})
+
+	t.Run("override the environment with flags", func(t *testing.T) {
+		c, args, err := parseCommand("test", []string{"-c", "from-flag.yaml", "-driver-options", "base_dir:/tmp", "extra"}, &bytes.Buffer{}, nil)
+		if err != nil {
+			t.Fatalf(errFmt, nil, err)
+		}
+
+		if c.ConfigPath != "from-flag.yaml" {
+			t.Errorf(errFmt, "from-flag.yaml", c.ConfigPath)
+		}
+
+		if c.DriverOptions["base_dir"] != "/tmp" {
+			t.Errorf(errFmt, "/tmp", c.DriverOptions["base_dir"])
+		}
+
+		if len(args) != 1 || args[0] != "extra" {
+			t.Errorf(errFmt, []string{"extra"}, args)
+		}
+	})
+
+	t.Run("prefer a url flag over a path from the environment", func(t *testing.T) {
+		c, _, err := parseCommand("test", []string{"-u", "http://127.0.0.1/mocks.yaml"}, &bytes.Buffer{}, nil)
+		if err != nil {
+			t.Fatalf(errFmt, nil, err)
+		}
+
+		if source := c.Source(); source != "http://127.0.0.1/mocks.yaml" {
+			t.Errorf(errFmt, "http://127.0.0.1/mocks.yaml", source)
+		}
+	})
+
+	t.Run("prefer a path flag over a url from the environment", func(t *testing.T) {
+		os.Unsetenv("CONFIG_PATH")
+		os.Setenv("CONFIG_URL", "http://127.0.0.1/mocks.yaml")
+		defer os.Setenv("CONFIG_PATH", "from-env.yaml")
+		defer os.Unsetenv("CONFIG_URL")
+
+		c, _, err := parseCommand("test", []string{"-c", "from-flag.yaml"}, &bytes.Buffer{}, nil)
+		if err != nil {
+			t.Fatalf(errFmt, nil, err)
+		}
+
+		if len(c.ConfigURL.String()) > 0 {
+			t.Errorf(errFmt, "", c.ConfigURL.String())
+		}
+	})
+}
+
+func TestRunShould(t *testing.T) {
+	t.Run("exit with a usage error on an unknown command", func(t *testing.T) {
+		var stderr bytes.Buffer
+		if status := run([]string{"unknown"}, &bytes.Buffer{}, &stderr); status != 2 {
+			t.Errorf(errFmt, 2, status)
+		}
+
+		if !strings.Contains(stderr.String(), "Usage:") {
+			t.Errorf(errFmt, "usage", stderr.String())
+		}
+	})
+
+	t.Run("convert a configuration to another format", func(t *testing.T) {
+		path := writeConfig(t, `
+- path: "/test"
+  method: GET
+  handlers:
+  - weight: 1
+    static_response: 'ok'
+    response_status: 200
+`)
+
+		var stdout bytes.Buffer
+		if status := run([]string{"convert", "-c", path, "-to", "json"}, &stdout, &bytes.Buffer{}); status != 0 {
+			t.Fatalf(errFmt, 0, status)
+		}
+
+		if !strings.Contains(stdout.String(), `"path": "/test"`) {
+			t.Errorf(errFmt, `"path": "/test"`, stdout.String())
+		}
+	})
+
+	t.Run("convert a configuration as written", func(t *testing.T) {
+		t.Setenv("TOKEN", "s3cret")
+		path := writeConfig(t, `
+- include: shared/health.yaml
+- path: "/test"
+  method: GET
+  request_headers:
+    Authorization: "Bearer ${TOKEN}"
+  handlers:
+  - weight: 1
+    response_path: bodies/b.txt
+    response_status: 200
+`)
+
+		var stdout bytes.Buffer
+		if status := run([]string{"convert", "-c", path, "-to", "toml"}, &stdout, &bytes.Buffer{}); status != 0 {
+			t.Fatalf(errFmt, 0, status)
+		}
+
+		for _, expected := range []string{`include = "shared/health.yaml"`, `Authorization = "Bearer ${TOKEN}"`, `response_path = "bodies/b.txt"`} {
+			if !strings.Contains(stdout.String(), expected) {
+				t.Errorf(errFmt, expected, stdout.String())
+			}
+		}
+	})
+
+	t.Run("omit unset integer fields when converting to toml", func(t *testing.T) {
+		path := writeConfig(t, `
+- path: "/test"
+  method: GET
+  handlers:
+  - static_response: ok
+`)
+
+		var stdout bytes.Buffer
+		if status := run([]string{"convert", "-c", path, "-to", "toml"}, &stdout, &bytes.Buffer{}); status != 0 {
+			t.Fatalf(errFmt, 0, status)
+		}
+
+		for _, unexpected := range []string{"priority", "weight", "response_status"} {
+			if strings.Contains(stdout.String(), unexpected) {
+				t.Errorf(errFmt, "no "+unexpected, stdout.String())
+			}
+		}
+	})
+
+	t.Run("refuse to convert to an unknown driver", func(t *testing.T) {
+		if status := run([]string{"convert", "-to", "unknown"}, &bytes.Buffer{}, &bytes.Buffer{}); status != 2 {
+			t.Errorf(errFmt, 2, status)
+		}
+	})
+}
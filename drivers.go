@@ -0,0 +1,69 @@
+package main
+
+import (
+	"path"
+	"strings"
+
+	"github.com/ncatelli/mockserver/pkg/config"
+	"github.com/ncatelli/mockserver/pkg/router/drivers"
+)
+
+// configFormat returns the format of the route configuration, preferring an
+// explicitly configured format over the extension of the configuration
+// source. Sources without a recognized extension default to yaml.
+func configFormat(c *config.Config) string {
+	if len(c.ConfigFormat) > 0 {
+		return strings.ToLower(c.ConfigFormat)
+	}
+
+	ext := path.Ext(c.ConfigPath)
+	if len(c.ConfigPath) == 0 {
+		ext = path.Ext(c.ConfigURL.Path)
+	}
+
+	switch ext {
+	case ".json":
+		return "json"
+	case ".toml":
+		return "toml"
+	default:
+		return "yaml"
+	}
+}
+
+// driverName returns the name of the driver used to load the route
+// configuration, preferring an explicitly configured driver over the driver
+// for the configuration's format.
+func driverName(c *config.Config) string {
+	if len(c.ConfigDriver) > 0 {
+		return c.ConfigDriver
+	}
+
+	return configFormat(c)
+}
+
+// driverForConfig looks up and initializes the driver used to load the route
+// configuration. RESPONSE_BASE_DIR is passed to the driver as the base_dir
+// option unless it has been explicitly set in the driver options.
+func driverForConfig(c *config.Config) (drivers.Driver, error) {
+	name := driverName(c)
+	d := drivers.Lookup(name)
+	if d == nil {
+		return nil, drivers.ErrUndefinedDriver{ID: name}
+	}
+
+	opts := make(map[string]string, len(c.DriverOptions)+1)
+	if len(c.ResponseBaseDir) > 0 {
+		opts["base_dir"] = c.ResponseBaseDir
+	}
+
+	for k, v := range c.DriverOptions {
+		opts[k] = v
+	}
+
+	if err := d.Init(opts); err != nil {
+		return nil, err
+	}
+
+	return d, nil
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/ncatelli/mockserver/pkg/config"
+	"github.com/ncatelli/mockserver/pkg/router/drivers"
+	"github.com/ncatelli/mockserver/pkg/router/drivers/simple"
+)
+
+func TestConfigFormatShould(t *testing.T) {
+	remote, _ := url.Parse("http://127.0.0.1/mocks.toml")
+
+	for _, tc := range []struct {
+		name     string
+		config   config.Config
+		expected string
+	}{
+		{name: "default to yaml", config: config.Config{ConfigPath: "mocks"}, expected: "yaml"},
+		{name: "detect json by extension", config: config.Config{ConfigPath: "mocks.json"}, expected: "json"},
+		{name: "detect toml by extension", config: config.Config{ConfigPath: "mocks/*.toml"}, expected: "toml"},
+		{name: "detect the extension of a url", config: config.Config{ConfigURL: *remote}, expected: "toml"},
+		{name: "prefer an explicit format", config: config.Config{ConfigPath: "mocks.json", ConfigFormat: "YAML"}, expected: "yaml"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if f := configFormat(&tc.config); f != tc.expected {
+				t.Errorf(errFmt, tc.expected, f)
+			}
+		})
+	}
+}
+
+func TestDriverForConfigShould(t *testing.T) {
+	t.Run("prefer an explicitly configured driver over the format", func(t *testing.T) {
+		c := &config.Config{ConfigPath: "mocks.json", ConfigDriver: "simple"}
+		d, err := driverForConfig(c)
+		if err != nil {
+			t.Fatalf(errFmt, nil, err)
+		}
+
+		if _, ok := d.(*simple.Driver); !ok {
+			t.Errorf(errFmt, simple.New(), d)
+		}
+	})
+
+	t.Run("pass the response base directory to the driver", func(t *testing.T) {
+		c := &config.Config{ConfigPath: "mocks.yaml", ResponseBaseDir: "/responses"}
+		d, err := driverForConfig(c)
+		if err != nil {
+			t.Fatalf(errFmt, nil, err)
+		}
+
+		if bd := d.(*simple.Driver).BaseDir; bd != "/responses" {
+			t.Errorf(errFmt, "/responses", bd)
+		}
+	})
+
+	t.Run("prefer an explicit base_dir driver option", func(t *testing.T) {
+		c := &config.Config{
+			ConfigPath:      "mocks.yaml",
+			ResponseBaseDir: "/responses",
+			DriverOptions:   config.Options{"base_dir": "/override"},
+		}
+		d, err := driverForConfig(c)
+		if err != nil {
+			t.Fatalf(errFmt, nil, err)
+		}
+
+		if bd := d.(*simple.Driver).BaseDir; bd != "/override" {
+			t.Errorf(errFmt, "/override", bd)
+		}
+	})
+
+	t.Run("return an error for an undefined driver", func(t *testing.T) {
+		_, err := driverForConfig(&config.Config{ConfigFormat: "xml"})
+		if _, ok := err.(drivers.ErrUndefinedDriver); !ok {
+			t.Errorf(errFmt, drivers.ErrUndefinedDriver{}, err)
+		}
+	})
+}
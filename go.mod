@@ -1,11 +1,18 @@
 module github.com/ncatelli/mockserver
 
 require (
+	github.com/BurntSushi/toml v1.2.1
 	github.com/caarlos0/env/v6 v6.1.0
+	github.com/fsnotify/fsnotify v1.5.4
 	github.com/gorilla/handlers v1.4.2
 	github.com/gorilla/mux v1.7.3
 	github.com/leekchan/gtf v0.0.0-20190214083521-5fba33c5b00b
-	gopkg.in/yaml.v2 v2.4.0
+	gopkg.in/yaml.v3 v3.0.1
+)
+
+require (
+	golang.org/x/sys v0.0.0-20220412211240-33da011f77ad // indirect
+	gopkg.in/yaml.v2 v2.4.0 // indirect
 )
 
 go 1.17
@@ -1,13 +1,180 @@
 package main
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/ncatelli/mockserver/pkg/state"
 )
 
+// adminPrefix is the path prefix of the built-in administration routes.
+const adminPrefix = "/__admin"
+
 // healthHandler takes a GET request and returns a 200 response to simulate a
 // health check.
 func healthHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
 	fmt.Fprintf(w, `{"status": "Ok"}`)
 }
+
+// registerAdminRoutes registers the built-in administration routes for
+// inspecting and driving scenario state against a router.
+func registerAdminRoutes(m *mux.Router, scenarios *state.Scenarios) {
+	a := scenarioAdmin{scenarios: scenarios}
+
+	m.HandleFunc(adminPrefix+"/scenarios", a.list).Methods("GET")
+	m.HandleFunc(adminPrefix+"/scenarios", a.resetAll).Methods("DELETE")
+	m.HandleFunc(adminPrefix+"/scenarios/{name}", a.get).Methods("GET")
+	m.HandleFunc(adminPrefix+"/scenarios/{name}", a.set).Methods("PUT")
+	m.HandleFunc(adminPrefix+"/scenarios/{name}", a.reset).Methods("DELETE")
+}
+
+// scenarioState is the representation of a single scenario served by the
+// administration routes.
+type scenarioState struct {
+	Name  string `json:"name"`
+	State string `json:"state"`
+}
+
+// scenarioAdmin serves the administration routes for a scenario store.
+type scenarioAdmin struct {
+	scenarios *state.Scenarios
+}
+
+// list responds with the current state of every known scenario.
+func (a scenarioAdmin) list(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, a.scenarios.States())
+}
+
+// resetAll returns every scenario to its initial state, responding with the
+// resulting states.
+func (a scenarioAdmin) resetAll(w http.ResponseWriter, r *http.Request) {
+	a.scenarios.ResetAll()
+	writeJSON(w, http.StatusOK, a.scenarios.States())
+}
+
+// get responds with the current state of a scenario.
+func (a scenarioAdmin) get(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["name"]
+	writeJSON(w, http.StatusOK, scenarioState{Name: name, State: a.scenarios.State(name)})
+}
+
+// set transitions a scenario to the state in the request body, e.g.
+// {"state": "created"}.
+func (a scenarioAdmin) set(w http.ResponseWriter, r *http.Request) {
+	var body scenarioState
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil || len(body.State) == 0 {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": `request body must be of the form {"state": "<state>"}`})
+		return
+	}
+
+	name := mux.Vars(r)["name"]
+	a.scenarios.Set(name, body.State)
+	writeJSON(w, http.StatusOK, scenarioState{Name: name, State: body.State})
+}
+
+// reset returns a scenario to its initial state.
+func (a scenarioAdmin) reset(w http.ResponseWriter, r *http.Request) {
+	name := mux.Vars(r)["name"]
+	a.scenarios.Reset(name)
+	writeJSON(w, http.StatusOK, scenarioState{Name: name, State: a.scenarios.State(name)})
+}
+
+// registerStateRoutes registers the built-in administration routes for
+// snapshotting, restoring and resetting a state store against a router.
+// Snapshots are saved to and restored from path, if it is set.
+func registerStateRoutes(m *mux.Router, store state.Store, path string) {
+	a := stateAdmin{store: store, path: path}
+
+	m.HandleFunc(adminPrefix+"/state", a.get).Methods("GET")
+	m.HandleFunc(adminPrefix+"/state/snapshot", a.snapshot).Methods("POST")
+	m.HandleFunc(adminPrefix+"/state/restore", a.restore).Methods("POST")
+	m.HandleFunc(adminPrefix+"/state/reset", a.reset).Methods("POST")
+}
+
+// errNoStatePath is returned when a snapshot is saved or restored without a
+// state path configured.
+var errNoStatePath = errors.New("no state path is configured")
+
+// stateAdmin serves the administration routes for a state store.
+type stateAdmin struct {
+	store state.Store
+	path  string
+}
+
+// get responds with a snapshot of the current state.
+func (a stateAdmin) get(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, a.store.Snapshot())
+}
+
+// snapshot saves a snapshot of the current state to the state path,
+// responding with the saved snapshot.
+func (a stateAdmin) snapshot(w http.ResponseWriter, r *http.Request) {
+	if len(a.path) == 0 {
+		writeError(w, http.StatusBadRequest, errNoStatePath)
+		return
+	}
+
+	if err := a.store.Save(a.path); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, a.store.Snapshot())
+}
+
+// restore replaces the current state with the snapshot in the request body,
+// or with the snapshot saved to the state path if the body is empty,
+// responding with the resulting state.
+func (a stateAdmin) restore(w http.ResponseWriter, r *http.Request) {
+	b, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	switch {
+	case len(bytes.TrimSpace(b)) > 0:
+		snapshot, err := state.DecodeSnapshot(b)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("request body must be a state snapshot: %v", err))
+			return
+		}
+
+		a.store.Restore(snapshot)
+	case len(a.path) == 0:
+		writeError(w, http.StatusBadRequest, errNoStatePath)
+		return
+	default:
+		if err := a.store.Load(a.path); err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+	}
+
+	writeJSON(w, http.StatusOK, a.store.Snapshot())
+}
+
+// reset returns every scenario to its initial state and every collection to
+// its seed items, responding with the resulting state.
+func (a stateAdmin) reset(w http.ResponseWriter, r *http.Request) {
+	a.store.Reset()
+	writeJSON(w, http.StatusOK, a.store.Snapshot())
+}
+
+// writeError responds with a JSON description of err.
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
+
+// writeJSON responds with the JSON encoding of v.
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
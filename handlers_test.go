@@ -5,7 +5,16 @@ import (
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"path/filepath"
+	"strings"
 	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/ncatelli/mockserver/pkg/state"
+)
+
+const (
+	errFmt string = "want %v, got %v"
 )
 
 func handlerTest(method, path string, reqBody io.Reader, respCode int, respBody string, h http.HandlerFunc) error {
@@ -45,3 +54,164 @@ func TestBuiltInRoutes(t *testing.T) {
 		}
 	})
 }
+
+// serveAdmin serves a request against a router, returning the recorded
+// response.
+func serveAdmin(m *mux.Router, method, path, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	rr := httptest.NewRecorder()
+	m.ServeHTTP(rr, req)
+	return rr
+}
+
+func TestScenarioAdminRoutesShould(t *testing.T) {
+	scenarios := state.NewScenarios()
+	scenarios.Register("orders")
+	m := mux.NewRouter()
+	registerAdminRoutes(m, scenarios)
+
+	t.Run("list the state of every scenario", func(t *testing.T) {
+		rr := serveAdmin(m, "GET", "/__admin/scenarios", "")
+		if expected := `{"orders":"started"}` + "\n"; rr.Body.String() != expected {
+			t.Errorf(errFmt, expected, rr.Body.String())
+		}
+	})
+
+	t.Run("set the state of a scenario", func(t *testing.T) {
+		rr := serveAdmin(m, "PUT", "/__admin/scenarios/orders", `{"state": "created"}`)
+		if rr.Code != http.StatusOK {
+			t.Errorf(errFmt, http.StatusOK, rr.Code)
+		}
+
+		if s := scenarios.State("orders"); s != "created" {
+			t.Errorf(errFmt, "created", s)
+		}
+	})
+
+	t.Run("return the state of a scenario", func(t *testing.T) {
+		rr := serveAdmin(m, "GET", "/__admin/scenarios/orders", "")
+		if expected := `{"name":"orders","state":"created"}` + "\n"; rr.Body.String() != expected {
+			t.Errorf(errFmt, expected, rr.Body.String())
+		}
+	})
+
+	t.Run("reject a state change without a state", func(t *testing.T) {
+		if rr := serveAdmin(m, "PUT", "/__admin/scenarios/orders", `{}`); rr.Code != http.StatusBadRequest {
+			t.Errorf(errFmt, http.StatusBadRequest, rr.Code)
+		}
+	})
+
+	t.Run("reset a scenario", func(t *testing.T) {
+		serveAdmin(m, "DELETE", "/__admin/scenarios/orders", "")
+		if s := scenarios.State("orders"); s != state.Initial {
+			t.Errorf(errFmt, state.Initial, s)
+		}
+	})
+
+	t.Run("reset every scenario", func(t *testing.T) {
+		scenarios.Set("orders", "created")
+		scenarios.Set("users", "deleted")
+		serveAdmin(m, "DELETE", "/__admin/scenarios", "")
+
+		for _, name := range []string{"orders", "users"} {
+			if s := scenarios.State(name); s != state.Initial {
+				t.Errorf(errFmt, state.Initial, s)
+			}
+		}
+	})
+}
+
+func TestStateAdminRoutesShould(t *testing.T) {
+	newStore := func() state.Store {
+		store := state.Store{Scenarios: state.NewScenarios(), Collections: state.NewCollections()}
+		store.Scenarios.Register("orders")
+		store.Collections.Declare("users", "id", nil)
+
+		return store
+	}
+
+	t.Run("return a snapshot of the state", func(t *testing.T) {
+		m := mux.NewRouter()
+		registerStateRoutes(m, newStore(), "")
+
+		rr := serveAdmin(m, "GET", "/__admin/state", "")
+		expected := `{"scenarios":{"orders":"started"},"collections":{"users":{"id_field":"id","items":[]}}}` + "\n"
+		if rr.Body.String() != expected {
+			t.Errorf(errFmt, expected, rr.Body.String())
+		}
+	})
+
+	t.Run("save and restore snapshots from the state path", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "state.json")
+		store := newStore()
+		m := mux.NewRouter()
+		registerStateRoutes(m, store, path)
+
+		store.Scenarios.Set("orders", "created")
+		if rr := serveAdmin(m, "POST", "/__admin/state/snapshot", ""); rr.Code != http.StatusOK {
+			t.Fatalf(errFmt, http.StatusOK, rr.Code)
+		}
+
+		store.Scenarios.Set("orders", "shipped")
+		if rr := serveAdmin(m, "POST", "/__admin/state/restore", ""); rr.Code != http.StatusOK {
+			t.Fatalf(errFmt, http.StatusOK, rr.Code)
+		}
+
+		if s := store.Scenarios.State("orders"); s != "created" {
+			t.Errorf(errFmt, "created", s)
+		}
+	})
+
+	t.Run("restore a snapshot from the request body", func(t *testing.T) {
+		store := newStore()
+		m := mux.NewRouter()
+		registerStateRoutes(m, store, "")
+
+		body := `{"collections":{"users":{"id_field":"id","items":[{"id":1,"name":"alice"}]}}}`
+		if rr := serveAdmin(m, "POST", "/__admin/state/restore", body); rr.Code != http.StatusOK {
+			t.Fatalf(errFmt, http.StatusOK, rr.Code)
+		}
+
+		if _, prs := store.Collections.Declare("users", "id", nil).Get("1"); !prs {
+			t.Errorf(errFmt, true, prs)
+		}
+	})
+
+	t.Run("reject saving or restoring without a state path", func(t *testing.T) {
+		m := mux.NewRouter()
+		registerStateRoutes(m, newStore(), "")
+
+		for _, path := range []string{"/__admin/state/snapshot", "/__admin/state/restore"} {
+			if rr := serveAdmin(m, "POST", path, ""); rr.Code != http.StatusBadRequest {
+				t.Errorf(errFmt, http.StatusBadRequest, rr.Code)
+			}
+		}
+	})
+
+	t.Run("reject restoring an invalid snapshot", func(t *testing.T) {
+		m := mux.NewRouter()
+		registerStateRoutes(m, newStore(), "")
+
+		if rr := serveAdmin(m, "POST", "/__admin/state/restore", `[]`); rr.Code != http.StatusBadRequest {
+			t.Errorf(errFmt, http.StatusBadRequest, rr.Code)
+		}
+	})
+
+	t.Run("reset the state", func(t *testing.T) {
+		store := newStore()
+		m := mux.NewRouter()
+		registerStateRoutes(m, store, "")
+
+		store.Scenarios.Set("orders", "created")
+		store.Collections.Declare("users", "id", nil).Insert(state.Item{"name": "alice"})
+		serveAdmin(m, "POST", "/__admin/state/reset", "")
+
+		if s := store.Scenarios.State("orders"); s != state.Initial {
+			t.Errorf(errFmt, state.Initial, s)
+		}
+
+		if items := store.Collections.Declare("users", "id", nil).List(); len(items) != 0 {
+			t.Errorf(errFmt, 0, len(items))
+		}
+	})
+}
@@ -2,11 +2,14 @@ package main
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log"
 	"os"
 	"os/signal"
 	"sync"
 	"syscall"
+	"time"
 
 	"github.com/gorilla/mux"
 
@@ -14,34 +17,103 @@ import (
 
 	"github.com/ncatelli/mockserver/pkg/config"
 	"github.com/ncatelli/mockserver/pkg/router"
-	"github.com/ncatelli/mockserver/pkg/router/drivers/simple"
+	"github.com/ncatelli/mockserver/pkg/router/drivers"
+	"github.com/ncatelli/mockserver/pkg/router/drivers/loader"
+	"github.com/ncatelli/mockserver/pkg/state"
 )
 
-func buildRouterFromConfig(c *config.Config) *mux.Router {
-	routes, err := simple.LoadFromFile(c.ConfigPath)
+// buildError describes a failure to build a router from the route
+// configuration, including the stage of the build that failed and the
+// configuration source being built.
+type buildError struct {
+	Stage  string
+	Source string
+	Err    error
+}
+
+func (e *buildError) Error() string {
+	return fmt.Sprintf("stage=%s source=%q error=%q", e.Stage, e.Source, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *buildError) Unwrap() error {
+	return e.Err
+}
+
+// loadRoutes fetches the route configuration from either the configured path
+// or URL and unmarshals it into a route slice using the driver for the
+// configuration's format. A configured path may refer to a single file, a
+// directory or a glob pattern.
+func loadRoutes(c *config.Config) ([]*router.Route, error) {
+	driver, err := driverForConfig(c)
 	if err != nil {
-		panic(err)
+		return nil, &buildError{Stage: "load", Source: c.Source(), Err: err}
 	}
 
-	router, err := router.New(routes)
+	if len(c.ConfigPath) > 0 {
+		routes, err := driver.LoadFromPath(c.ConfigPath)
+		if err != nil {
+			// distinguish failures to read files from failures to parse them.
+			stage := "parse"
+			var pathErr *os.PathError
+			var noFilesErr loader.ErrNoConfigFiles
+			if errors.As(err, &pathErr) || errors.As(err, &noFilesErr) {
+				stage = "load"
+			}
+
+			return nil, &buildError{Stage: stage, Source: c.Source(), Err: err}
+		}
+
+		return routes, nil
+	}
+
+	data, err := c.Load()
 	if err != nil {
-		panic(err)
+		return nil, &buildError{Stage: "load", Source: c.Source(), Err: err}
 	}
 
-	return router
+	var routes []*router.Route
+	if ud, ok := driver.(drivers.URLDriver); ok {
+		routes, err = ud.LoadFromURL(&c.ConfigURL, data)
+	} else {
+		routes, err = driver.Load(data)
+	}
+	if err != nil {
+		return nil, &buildError{Stage: "parse", Source: c.Source(), Err: err}
+	}
+
+	return routes, nil
 }
 
-func startHTTPServer(c *config.Config, wg *sync.WaitGroup) *http.Server {
-	router := buildRouterFromConfig(c)
+// buildRouterFromConfig loads the route configuration and returns both the
+// initialized routes and a router serving them alongside the built-in routes.
+// The router is fully validated before returning, so a successful build is
+// safe to serve.
+func buildRouterFromConfig(c *config.Config) ([]*router.Route, *mux.Router, error) {
+	routes, err := loadRoutes(c)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	router, err := router.New(routes)
+	if err != nil {
+		return nil, nil, &buildError{Stage: "build", Source: c.Source(), Err: err}
+	}
+
 	router.HandleFunc(`/healthcheck`, healthHandler).Methods("GET")
+	registerAdminRoutes(router, state.DefaultScenarios)
+	registerStateRoutes(router, state.Default, c.StatePath)
+
+	return routes, router, nil
+}
+
+func startHTTPServer(c *config.Config, handler http.Handler) *http.Server {
 	srv := &http.Server{
 		Addr:    c.Addr,
-		Handler: router,
+		Handler: handler,
 	}
 
 	go func() {
-		defer wg.Done() // let main know we are done cleaning up
-
 		// always returns error. ErrServerClosed on graceful close
 		if err := srv.ListenAndServe(); err != http.ErrServerClosed {
 			// unexpected error. port in use?
@@ -53,33 +125,168 @@ func startHTTPServer(c *config.Config, wg *sync.WaitGroup) *http.Server {
 	return srv
 }
 
-func main() {
+// watchForChanges starts any configured change detection for the route
+// configuration, sending on changed when a reload should occur. Watching
+// stops once done is closed and all watchers have returned, at which point
+// the returned WaitGroup is released. The returned fileWatcher, which is nil
+// unless the configuration is loaded from files, should be passed the paths
+// of each newly loaded configuration.
+func watchForChanges(c *config.Config, routes []*router.Route, done <-chan struct{}, changed chan<- struct{}) (*fileWatcher, *sync.WaitGroup) {
+	wg := &sync.WaitGroup{}
+
+	if c.Pollable() {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c.Poll(done, changed)
+		}()
+	}
+
+	// only file based drivers are watched.
+	d, _ := driverForConfig(c)
+	if fd, ok := d.(drivers.FileDriver); ok && len(c.ConfigPath) > 0 {
+		w, err := newFileWatcher(fd.Extensions())
+		if err == nil {
+			err = w.Watch(watchedPaths(c, routes))
+		}
+
+		if err != nil {
+			log.Printf("unable to watch route configuration: %v\n", err)
+			return nil, wg
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer w.Close()
+			if err := w.Run(done, changed); err != nil {
+				log.Printf("unable to watch route configuration: %v\n", err)
+			}
+		}()
+
+		return w, wg
+	}
+
+	return nil, wg
+}
+
+// shutdownTimeout is the maximum time to wait on in-flight requests when
+// shutting down.
+const shutdownTimeout = 5 * time.Second
+
+// loadState restores the shared state from the configured state path. A
+// state path that doesn't exist yet is ignored, as it is created the first
+// time state is saved.
+func loadState(c *config.Config) error {
+	if len(c.StatePath) == 0 {
+		return nil
+	}
+
+	err := state.Default.Load(c.StatePath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+
+	return err
+}
+
+// saveState saves the shared state to the configured state path, logging
+// any failure to do so.
+func saveState(c *config.Config) {
+	if len(c.StatePath) == 0 {
+		return
+	}
+
+	if err := state.Default.Save(c.StatePath); err != nil {
+		log.Printf("unable to save state: %v\n", err)
+	}
+}
+
+// serve builds a router from the route configuration and serves it, reloading
+// the route configuration on SIGHUP or any detected change. When a state path
+// is configured, state is restored from it on start and saved to it on each
+// reload and on shutdown. serve returns once the server is stopped by SIGINT
+// or SIGTERM.
+func serve(c config.Config) {
 	sigs := make(chan os.Signal, 1)
 	signal.Notify(sigs, syscall.SIGHUP)
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
 
-	for {
-		c, e := config.New()
-		if e != nil {
-			log.Fatal("unable to parse config params")
-		}
+	if err := loadState(&c); err != nil {
+		log.Fatalf("unable to restore state: %v\n", err)
+	}
 
-		log.Printf("Starting server on %s\n", c.Addr)
+	routes, r, err := buildRouterFromConfig(&c)
+	if err != nil {
+		log.Fatalf("unable to start: %v\n", err)
+	}
 
-		httpServerExitDone := &sync.WaitGroup{}
-		httpServerExitDone.Add(1)
-		srv := startHTTPServer(&c, httpServerExitDone)
+	handler := &swapHandler{}
+	handler.Swap(r)
+	logRoutes(routes)
 
-		// blocks for shutdown. If a SIGHUP happens it will gracefully
-		// restart the server.
-		<-sigs
+	log.Printf("Starting server on %s\n", c.Addr)
+	srv := startHTTPServer(&c, handler)
+
+	// watchers run for the lifetime of the server so that changes made
+	// while a reload is in progress trigger another reload.
+	done := make(chan struct{})
+	changed := make(chan struct{})
+	watcher, watchers := watchForChanges(&c, routes, done, changed)
+
+	for {
+		// blocks until a reload or shutdown is requested. If a SIGHUP happens
+		// or the route configuration changes, the router is rebuilt and
+		// swapped in place without restarting the server.
+		var stopping bool
+		select {
+		case <-sigs:
+		case <-changed:
+			log.Println("route configuration changed")
+		case <-stop:
+			stopping = true
+		}
+
+		saveState(&c)
+		if stopping {
+			close(done)
+			watchers.Wait()
+			log.Println("shutting down...")
+			ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+			srv.Shutdown(ctx)
+			cancel()
+			for _, route := range routes {
+				route.Close()
+			}
+			return
+		}
 
 		log.Println("reloading configuration...")
 
-		if err := srv.Shutdown(context.TODO()); err != nil {
-			panic(err) // failure/timeout shutting down the server gracefully
+		// the previous router continues to serve if the new configuration
+		// fails to build.
+		newRoutes, r, err := buildRouterFromConfig(&c)
+		if err != nil {
+			log.Printf("reload failed, continuing with previous configuration: %v\n", err)
+			continue
+		}
+
+		handler.Swap(r)
+		logRoutes(newRoutes)
+		if watcher != nil {
+			if err := watcher.Watch(watchedPaths(&c, newRoutes)); err != nil {
+				log.Printf("unable to watch route configuration: %v\n", err)
+			}
 		}
 
-		// wait for goroutine started in startHttpServer() to stop
-		httpServerExitDone.Wait()
+		for _, route := range routes {
+			route.Close()
+		}
+		routes = newRoutes
 	}
 }
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ncatelli/mockserver/pkg/config"
+)
+
+func writeConfig(t *testing.T, content string) string {
+	dir, err := ioutil.TempDir("", "mockserver")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	path := filepath.Join(dir, "mocks.yaml")
+	if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+func TestBuildRouterFromConfigShould(t *testing.T) {
+	t.Run("build a router from a valid configuration", func(t *testing.T) {
+		c := &config.Config{ConfigPath: writeConfig(t, `
+- path: "/test"
+  method: GET
+  handlers:
+  - weight: 1
+    static_response: 'ok'
+    response_status: 200
+`)}
+
+		routes, _, err := buildRouterFromConfig(c)
+		if err != nil {
+			t.Fatalf(errFmt, nil, err)
+		}
+
+		for _, route := range routes {
+			route.Close()
+		}
+	})
+
+	for _, tc := range []struct {
+		name    string
+		content string
+		stage   string
+	}{
+		{name: "unparseable yaml", content: ";189na--ac", stage: "parse"},
+		{name: "an invalid template", content: `
+- path: "/test"
+  method: GET
+  handlers:
+  - weight: 1
+    static_response: '{{ .PathVars'
+    response_status: 200
+`, stage: "build"},
+	} {
+		t.Run("return a build error with the failing stage for "+tc.name, func(t *testing.T) {
+			c := &config.Config{ConfigPath: writeConfig(t, tc.content)}
+
+			_, _, err := buildRouterFromConfig(c)
+
+			var be *buildError
+			if !errors.As(err, &be) {
+				t.Fatalf(errFmt, &buildError{}, err)
+			}
+
+			if be.Stage != tc.stage {
+				t.Errorf(errFmt, tc.stage, be.Stage)
+			}
+		})
+	}
+
+	t.Run("resolve relative includes within a configuration URL against the URL", func(t *testing.T) {
+		mux := http.NewServeMux()
+		mux.HandleFunc("/mocks/main.yaml", func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, "- include: shared/health.yaml\n")
+		})
+		mux.HandleFunc("/mocks/shared/health.yaml", func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, "- path: /health\n  method: GET\n  handlers:\n  - weight: 1\n    response_status: 204\n")
+		})
+		srv := httptest.NewServer(mux)
+		defer srv.Close()
+
+		u, err := url.Parse(srv.URL + "/mocks/main.yaml")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		c := &config.Config{ConfigURL: *u, ConfigTimeout: time.Second}
+		routes, _, err := buildRouterFromConfig(c)
+		if err != nil {
+			t.Fatalf(errFmt, nil, err)
+		}
+		defer func() {
+			for _, route := range routes {
+				route.Close()
+			}
+		}()
+
+		if len(routes) != 1 || routes[0].Path != "/health" {
+			t.Errorf(errFmt, "/health", routes)
+		}
+	})
+
+	t.Run("return a build error with the load stage for a missing configuration", func(t *testing.T) {
+		c := &config.Config{ConfigPath: "test_fixtures/this_file_should_not_exist.yaml"}
+
+		_, _, err := buildRouterFromConfig(c)
+
+		var be *buildError
+		if !errors.As(err, &be) || be.Stage != "load" {
+			t.Errorf(errFmt, "load", err)
+		}
+	})
+}
+
+func TestReloadShould(t *testing.T) {
+	t.Run("keep the previous middleware configuration when a reload fails", func(t *testing.T) {
+		path := writeConfig(t, `
+- path: "/items"
+  middleware:
+    latency:
+      latency: "1"
+  resource:
+    name: reload_items
+`)
+		c := &config.Config{ConfigPath: path}
+
+		routes, r, err := buildRouterFromConfig(c)
+		if err != nil {
+			t.Fatalf(errFmt, nil, err)
+		}
+		defer func() {
+			for _, route := range routes {
+				route.Close()
+			}
+		}()
+
+		// the rejected configuration changes the latency of the served route
+		// ahead of a route that fails to initialize.
+		if err := ioutil.WriteFile(path, []byte(`
+- path: "/items"
+  middleware:
+    latency:
+      latency: "300"
+  resource:
+    name: reload_items
+- path: "/broken"
+  method: GET
+  middleware:
+    latency:
+      latency: abc
+  handlers:
+  - weight: 1
+    response_status: 200
+`), 0600); err != nil {
+			t.Fatal(err)
+		}
+
+		var be *buildError
+		if _, _, err := buildRouterFromConfig(c); !errors.As(err, &be) || be.Stage != "build" {
+			t.Fatalf(errFmt, "build", err)
+		}
+
+		start := time.Now()
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, httptest.NewRequest("GET", "/items", nil))
+		if d := time.Since(start); d >= 150*time.Millisecond {
+			t.Errorf(errFmt, "less than 150ms", d)
+		}
+
+		if rr.Code != http.StatusOK {
+			t.Errorf(errFmt, http.StatusOK, rr.Code)
+		}
+	})
+}
@@ -2,11 +2,13 @@ package config
 
 import (
 	"bytes"
+	"fmt"
 	"io"
 	"io/ioutil"
-	"net/http"
 	"net/url"
 	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/caarlos0/env/v6"
 )
@@ -18,13 +20,57 @@ func (e *ErrUndefinedConfig) Error() string {
 	return "route configuration has not been specified"
 }
 
+// ErrUnexpectedStatus represents a remote route configuration responding with
+// a non-2xx status code.
+type ErrUnexpectedStatus struct {
+	URL        string
+	StatusCode int
+}
+
+func (e *ErrUnexpectedStatus) Error() string {
+	return fmt.Sprintf("fetching route configuration from %s returned unexpected status %d", e.URL, e.StatusCode)
+}
+
+// Options represents a set of key-value options parsed from a comma
+// separated list of key:value pairs.
+type Options map[string]string
+
+// UnmarshalText implements the encoding.TextUnmarshaler interface, parsing a
+// comma separated list of key:value pairs. Values may contain colons.
+func (o *Options) UnmarshalText(text []byte) error {
+	opts := make(Options)
+	for _, pair := range strings.Split(string(text), ",") {
+		if len(pair) == 0 {
+			continue
+		}
+
+		kv := strings.SplitN(pair, ":", 2)
+		if len(kv) != 2 {
+			return fmt.Errorf("invalid option %q, must be in key:value format", pair)
+		}
+
+		opts[kv[0]] = kv[1]
+	}
+
+	*o = opts
+	return nil
+}
+
 // Config stores configuration parameters for interacting with the server at a
 // global level. This can include listening address, feature flags and other
 // configurations.
 type Config struct {
-	Addr       string  `env:"ADDR" envDefault:"0.0.0.0:8080"`
-	ConfigPath string  `env:"CONFIG_PATH"`
-	ConfigURL  url.URL `env:"CONFIG_URL"`
+	Addr            string        `env:"ADDR" envDefault:"0.0.0.0:8080"`
+	ConfigPath      string        `env:"CONFIG_PATH"`
+	ConfigURL       url.URL       `env:"CONFIG_URL"`
+	ConfigFormat    string        `env:"CONFIG_FORMAT"`
+	ConfigDriver    string        `env:"CONFIG_DRIVER"`
+	DriverOptions   Options       `env:"CONFIG_DRIVER_OPTIONS"`
+	ConfigTimeout   time.Duration `env:"CONFIG_TIMEOUT" envDefault:"10s"`
+	PollInterval    time.Duration `env:"CONFIG_POLL_INTERVAL"`
+	ResponseBaseDir string        `env:"RESPONSE_BASE_DIR"`
+	StatePath       string        `env:"STATE_PATH"`
+	remote          remoteState
 }
 
 // New initializes a Config, attempting to parse parames from Envs.
@@ -38,6 +84,17 @@ func New() (Config, error) {
 	return c, nil
 }
 
+// Source returns the location the route configuration is loaded from,
+// following the same precedence as Load. An empty string is returned if no
+// location has been specified.
+func (c *Config) Source() string {
+	if len(c.ConfigPath) > 0 {
+		return c.ConfigPath
+	}
+
+	return c.ConfigURL.String()
+}
+
 // Load attempts to fetch a Router configuration from one of the optional
 // locations (URL or Filepath). On success it returns an io.Reader for this
 // file otherwise an error is returned.
@@ -50,13 +107,7 @@ func (c *Config) Load() (io.Reader, error) {
 
 		return bytes.NewReader(b), nil
 	} else if len(c.ConfigURL.String()) > 0 {
-		resp, err := http.Get(c.ConfigURL.String())
-		if err != nil {
-			return nil, err
-		}
-
-		defer resp.Body.Close()
-		b, err := ioutil.ReadAll(resp.Body)
+		b, _, err := c.fetchRemote(false)
 		if err != nil {
 			return nil, err
 		}
@@ -8,6 +8,7 @@ import (
 	"os"
 	"reflect"
 	"testing"
+	"time"
 )
 
 const (
@@ -60,6 +61,32 @@ func TestInitializingAConfigShould(t *testing.T) {
 	})
 }
 
+func TestInitializingDriverConfigShould(t *testing.T) {
+	t.Run("parse driver options as a map from an env", func(t *testing.T) {
+		os.Setenv("CONFIG_DRIVER_OPTIONS", "base_dir:/responses,dsn:postgres://mocks")
+		defer os.Unsetenv("CONFIG_DRIVER_OPTIONS")
+
+		c, err := New()
+		if err != nil {
+			t.Error(err)
+		}
+
+		expected := Options{"base_dir": "/responses", "dsn": "postgres://mocks"}
+		if !reflect.DeepEqual(expected, c.DriverOptions) {
+			t.Errorf(errFmt, expected, c.DriverOptions)
+		}
+	})
+
+	t.Run("return an error when driver options are malformed", func(t *testing.T) {
+		os.Setenv("CONFIG_DRIVER_OPTIONS", "base_dir")
+		defer os.Unsetenv("CONFIG_DRIVER_OPTIONS")
+
+		if _, err := New(); err == nil {
+			t.Errorf(errFmt, "error", err)
+		}
+	})
+}
+
 func TestConfigurationLoadingShould(t *testing.T) {
 	t.Run("return an ErrUnspecifiedConfig when no config option is set", func(t *testing.T) {
 		c := Config{}
@@ -99,6 +126,41 @@ func TestConfigurationLoadingShould(t *testing.T) {
 		}
 	})
 
+	t.Run("return an ErrUnexpectedStatus when the URL responds with a non-2xx status", func(t *testing.T) {
+		testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNotFound)
+		}))
+		defer func() { testServer.Close() }()
+
+		testURL, _ := url.Parse(testServer.URL)
+		c := Config{
+			ConfigURL: *testURL,
+		}
+
+		_, err := c.Load()
+		if _, ok := err.(*ErrUnexpectedStatus); !ok {
+			t.Errorf(errFmt, &ErrUnexpectedStatus{}, err)
+		}
+	})
+
+	t.Run("return an error when the URL doesn't respond within the timeout", func(t *testing.T) {
+		testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			time.Sleep(100 * time.Millisecond)
+			w.Write([]byte(goodResponseBody))
+		}))
+		defer func() { testServer.Close() }()
+
+		testURL, _ := url.Parse(testServer.URL)
+		c := Config{
+			ConfigURL:     *testURL,
+			ConfigTimeout: 10 * time.Millisecond,
+		}
+
+		if _, err := c.Load(); err == nil {
+			t.Errorf(errFmt, "error", err)
+		}
+	})
+
 	t.Run("load a configuration from a filepath when specified", func(t *testing.T) {
 		c := Config{
 			ConfigPath: goodTestFixturePath,
@@ -0,0 +1,117 @@
+package config
+
+import (
+	"crypto/sha256"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"time"
+)
+
+// remoteState tracks the validators and content digest of the last remote
+// configuration fetched from a ConfigURL.
+type remoteState struct {
+	etag         string
+	lastModified string
+	digest       [sha256.Size]byte
+}
+
+// fetchRemote requests the configuration from ConfigURL, recording the
+// validators and digest of the response. When conditional is set, the
+// previously recorded validators are sent along with the request and a
+// notModified value of true is returned if the server responds with a 304.
+func (c *Config) fetchRemote(conditional bool) (body []byte, notModified bool, err error) {
+	req, err := http.NewRequest(http.MethodGet, c.ConfigURL.String(), nil)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if conditional {
+		if len(c.remote.etag) > 0 {
+			req.Header.Set("If-None-Match", c.remote.etag)
+		}
+
+		if len(c.remote.lastModified) > 0 {
+			req.Header.Set("If-Modified-Since", c.remote.lastModified)
+		}
+	}
+
+	client := &http.Client{Timeout: c.ConfigTimeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, false, err
+	}
+
+	defer resp.Body.Close()
+	if conditional && resp.StatusCode == http.StatusNotModified {
+		return nil, true, nil
+	} else if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, false, &ErrUnexpectedStatus{
+			URL:        c.ConfigURL.String(),
+			StatusCode: resp.StatusCode,
+		}
+	}
+
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, false, err
+	}
+
+	c.remote.etag = resp.Header.Get("ETag")
+	c.remote.lastModified = resp.Header.Get("Last-Modified")
+	c.remote.digest = sha256.Sum256(b)
+
+	return b, false, nil
+}
+
+// Changed performs a conditional request against the ConfigURL, returning
+// true if the remote configuration differs from the one last fetched. A
+// document is considered unchanged if the server responds with a 304 or if
+// the content of the response matches the last fetched document.
+func (c *Config) Changed() (bool, error) {
+	previous := c.remote.digest
+
+	b, notModified, err := c.fetchRemote(true)
+	if err != nil {
+		return false, err
+	} else if notModified {
+		return false, nil
+	}
+
+	return sha256.Sum256(b) != previous, nil
+}
+
+// Poll checks the ConfigURL for changes every PollInterval, sending on the
+// changed channel each time the remote configuration differs from the one
+// last fetched. Polling continues until the done channel is closed.
+func (c *Config) Poll(done <-chan struct{}, changed chan<- struct{}) {
+	ticker := time.NewTicker(c.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			ok, err := c.Changed()
+			if err != nil {
+				log.Printf("unable to poll route configuration: %v\n", err)
+				continue
+			}
+
+			if ok {
+				select {
+				case changed <- struct{}{}:
+				case <-done:
+					return
+				}
+			}
+		}
+	}
+}
+
+// Pollable returns true if the Config is sourced from a ConfigURL and a
+// PollInterval has been set.
+func (c *Config) Pollable() bool {
+	return len(c.ConfigPath) == 0 && len(c.ConfigURL.String()) > 0 && c.PollInterval > 0
+}
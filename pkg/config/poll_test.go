@@ -0,0 +1,132 @@
+package config
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestConfigurationChangeDetectionShould(t *testing.T) {
+	t.Run("report no change when the server responds with a 304", func(t *testing.T) {
+		etag := `"v1"`
+		testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("If-None-Match") == etag {
+				w.WriteHeader(http.StatusNotModified)
+				return
+			}
+
+			w.Header().Set("ETag", etag)
+			w.Write([]byte(goodResponseBody))
+		}))
+		defer func() { testServer.Close() }()
+
+		testURL, _ := url.Parse(testServer.URL)
+		c := Config{
+			ConfigURL: *testURL,
+		}
+
+		if _, err := c.Load(); err != nil {
+			t.Fatalf(errFmt, nil, err)
+		}
+
+		changed, err := c.Changed()
+		if err != nil {
+			t.Errorf(errFmt, nil, err)
+		}
+
+		if changed {
+			t.Errorf(errFmt, false, changed)
+		}
+	})
+
+	t.Run("report no change when the content is unchanged", func(t *testing.T) {
+		testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(goodResponseBody))
+		}))
+		defer func() { testServer.Close() }()
+
+		testURL, _ := url.Parse(testServer.URL)
+		c := Config{
+			ConfigURL: *testURL,
+		}
+
+		if _, err := c.Load(); err != nil {
+			t.Fatalf(errFmt, nil, err)
+		}
+
+		changed, err := c.Changed()
+		if err != nil {
+			t.Errorf(errFmt, nil, err)
+		}
+
+		if changed {
+			t.Errorf(errFmt, false, changed)
+		}
+	})
+
+	t.Run("report a change when the content differs", func(t *testing.T) {
+		body := goodResponseBody
+		testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(body))
+		}))
+		defer func() { testServer.Close() }()
+
+		testURL, _ := url.Parse(testServer.URL)
+		c := Config{
+			ConfigURL: *testURL,
+		}
+
+		if _, err := c.Load(); err != nil {
+			t.Fatalf(errFmt, nil, err)
+		}
+
+		body = "[]"
+		changed, err := c.Changed()
+		if err != nil {
+			t.Errorf(errFmt, nil, err)
+		}
+
+		if !changed {
+			t.Errorf(errFmt, true, changed)
+		}
+	})
+}
+
+func TestConfigurationPollingShould(t *testing.T) {
+	t.Run("notify when the remote configuration changes", func(t *testing.T) {
+		body := make(chan string, 1)
+		body <- goodResponseBody
+		testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			b := <-body
+			body <- b
+			w.Write([]byte(b))
+		}))
+		defer func() { testServer.Close() }()
+
+		testURL, _ := url.Parse(testServer.URL)
+		c := Config{
+			ConfigURL:    *testURL,
+			PollInterval: 10 * time.Millisecond,
+		}
+
+		if _, err := c.Load(); err != nil {
+			t.Fatalf(errFmt, nil, err)
+		}
+
+		done := make(chan struct{})
+		changed := make(chan struct{})
+		defer close(done)
+		go c.Poll(done, changed)
+
+		<-body
+		body <- "[]"
+
+		select {
+		case <-changed:
+		case <-time.After(time.Second):
+			t.Errorf(errFmt, "a change notification", nil)
+		}
+	})
+}
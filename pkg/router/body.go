@@ -0,0 +1,391 @@
+package router
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// BodyMatcher describes conditions on the body of a request. Every condition
+// that is set must be satisfied for the body to match.
+type BodyMatcher struct {
+	// Equals requires the body to exactly equal the value.
+	Equals string `yaml:"equals,omitempty" json:"equals,omitempty" toml:"equals,omitempty"`
+
+	// Regex requires the body to match the regular expression.
+	Regex string `yaml:"regex,omitempty" json:"regex,omitempty" toml:"regex,omitempty"`
+
+	// JSON requires the body to be a JSON document that contains the value.
+	// Objects match if every field of the value is present and matches,
+	// allowing additional fields in the body. Arrays must be the same
+	// length and each element must match. All other values must be equal.
+	JSON interface{} `yaml:"json,omitempty" json:"json,omitempty" toml:"json,omitempty"`
+
+	// JSONPath requires the body to be a JSON document, with the values
+	// selected by each path satisfying the corresponding matcher.
+	JSONPath map[string]Matcher `yaml:"json_path,omitempty" json:"json_path,omitempty" toml:"json_path,omitempty"`
+
+	// Form requires the body to be form encoded, with the values of each
+	// field satisfying the corresponding matcher.
+	Form map[string]Matcher `yaml:"form,omitempty" json:"form,omitempty" toml:"form,omitempty"`
+}
+
+// bodyMatcherFields has the fields of a BodyMatcher without its methods, so
+// that it can be decoded without recursing into UnmarshalYAML.
+type bodyMatcherFields BodyMatcher
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface. The JSON value is
+// normalized to the types produced by decoding JSON, as yaml decodes numbers
+// into integer types that other formats encode differently.
+func (b *BodyMatcher) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	if err := unmarshal((*bodyMatcherFields)(b)); err != nil {
+		return err
+	}
+
+	if b.JSON == nil {
+		return nil
+	}
+
+	v, err := normalizeJSON(b.JSON)
+	if err != nil {
+		return fmt.Errorf("json: %v", err)
+	}
+
+	b.JSON = v
+	return nil
+}
+
+// compile returns a function reporting whether a request body satisfies the
+// matcher.
+func (b *BodyMatcher) compile() (func([]byte) bool, error) {
+	var conditions []func([]byte) bool
+
+	if len(b.Equals) > 0 {
+		conditions = append(conditions, func(body []byte) bool {
+			return string(body) == b.Equals
+		})
+	}
+
+	if len(b.Regex) > 0 {
+		re, err := regexp.Compile(b.Regex)
+		if err != nil {
+			return nil, fmt.Errorf("regex: %v", err)
+		}
+
+		conditions = append(conditions, re.Match)
+	}
+
+	if b.JSON != nil {
+		expected, err := normalizeJSON(b.JSON)
+		if err != nil {
+			return nil, fmt.Errorf("json: %v", err)
+		}
+
+		conditions = append(conditions, func(body []byte) bool {
+			var actual interface{}
+			if err := json.Unmarshal(body, &actual); err != nil {
+				return false
+			}
+
+			return jsonContains(actual, expected)
+		})
+	}
+
+	if len(b.JSONPath) > 0 {
+		paths := make(map[string]jsonPath, len(b.JSONPath))
+		matches := make(map[string]func([]string) bool, len(b.JSONPath))
+		for p, m := range b.JSONPath {
+			path, err := parseJSONPath(p)
+			if err != nil {
+				return nil, fmt.Errorf("json_path %s: %v", p, err)
+			}
+
+			match, err := m.compile()
+			if err != nil {
+				return nil, fmt.Errorf("json_path %s: %v", p, err)
+			}
+
+			paths[p], matches[p] = path, match
+		}
+
+		conditions = append(conditions, func(body []byte) bool {
+			var doc interface{}
+			if err := json.Unmarshal(body, &doc); err != nil {
+				return false
+			}
+
+			for p, path := range paths {
+				if !matches[p](jsonStrings(path.eval(doc))) {
+					return false
+				}
+			}
+
+			return true
+		})
+	}
+
+	if len(b.Form) > 0 {
+		matches := make(map[string]func([]string) bool, len(b.Form))
+		for k, m := range b.Form {
+			match, err := m.compile()
+			if err != nil {
+				return nil, fmt.Errorf("form %s: %v", k, err)
+			}
+
+			matches[k] = match
+		}
+
+		conditions = append(conditions, func(body []byte) bool {
+			values, err := url.ParseQuery(string(body))
+			if err != nil {
+				return false
+			}
+
+			for k, match := range matches {
+				if !match(values[k]) {
+					return false
+				}
+			}
+
+			return true
+		})
+	}
+
+	if len(conditions) == 0 {
+		return nil, fmt.Errorf("at least one of equals, regex, json, json_path or form must be set")
+	}
+
+	return func(body []byte) bool {
+		for _, c := range conditions {
+			if !c(body) {
+				return false
+			}
+		}
+
+		return true
+	}, nil
+}
+
+// validate returns an error describing why the matcher is invalid, or nil if
+// it is valid.
+func (b *BodyMatcher) validate() error {
+	for _, m := range b.JSONPath {
+		if err := m.validate(); err != nil {
+			return err
+		}
+	}
+
+	for _, m := range b.Form {
+		if err := m.validate(); err != nil {
+			return err
+		}
+	}
+
+	_, err := b.compile()
+	return err
+}
+
+// Describe formats the conditions of the matcher as a comma separated list.
+func (b *BodyMatcher) Describe() string {
+	var descriptions []string
+	if len(b.Equals) > 0 {
+		descriptions = append(descriptions, "equals")
+	}
+
+	if len(b.Regex) > 0 {
+		descriptions = append(descriptions, "~"+b.Regex)
+	}
+
+	if b.JSON != nil {
+		descriptions = append(descriptions, "json")
+	}
+
+	paths := make([]string, 0, len(b.JSONPath))
+	for p, m := range b.JSONPath {
+		paths = append(paths, m.Describe(p))
+	}
+	sort.Strings(paths)
+
+	fields := make([]string, 0, len(b.Form))
+	for k, m := range b.Form {
+		fields = append(fields, "form:"+m.Describe(k))
+	}
+	sort.Strings(fields)
+
+	return strings.Join(append(append(descriptions, paths...), fields...), ",")
+}
+
+// readBody reads the body of a request, replacing it so that it can be read
+// again by subsequent matchers and handlers.
+func readBody(r *http.Request) ([]byte, error) {
+	if r.Body == nil {
+		return nil, nil
+	}
+
+	b, err := ioutil.ReadAll(r.Body)
+	r.Body.Close()
+	r.Body = ioutil.NopCloser(bytes.NewReader(b))
+
+	return b, err
+}
+
+// normalizeJSON converts a decoded value into the types produced by decoding
+// JSON, so that values decoded from any configuration format can be compared
+// against a decoded request body.
+func normalizeJSON(v interface{}) (interface{}, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var normalized interface{}
+	err = json.Unmarshal(b, &normalized)
+	return normalized, err
+}
+
+// jsonContains returns true if actual contains expected. Objects may contain
+// additional fields, arrays must be the same length with each element
+// containing the corresponding expected element, and all other values must
+// be equal.
+func jsonContains(actual, expected interface{}) bool {
+	switch e := expected.(type) {
+	case map[string]interface{}:
+		a, ok := actual.(map[string]interface{})
+		if !ok {
+			return false
+		}
+
+		for k, v := range e {
+			av, prs := a[k]
+			if !prs || !jsonContains(av, v) {
+				return false
+			}
+		}
+
+		return true
+	case []interface{}:
+		a, ok := actual.([]interface{})
+		if !ok || len(a) != len(e) {
+			return false
+		}
+
+		for i := range e {
+			if !jsonContains(a[i], e[i]) {
+				return false
+			}
+		}
+
+		return true
+	}
+
+	return reflect.DeepEqual(actual, expected)
+}
+
+// jsonStrings formats selected JSON values for comparison by a Matcher.
+// Strings are passed through unquoted, while all other values are formatted
+// as JSON.
+func jsonStrings(values []interface{}) []string {
+	s := make([]string, 0, len(values))
+	for _, v := range values {
+		if str, ok := v.(string); ok {
+			s = append(s, str)
+			continue
+		}
+
+		b, _ := json.Marshal(v)
+		s = append(s, string(b))
+	}
+
+	return s
+}
+
+// jsonPathWildcard selects every element of an array or field of an object.
+const jsonPathWildcard = "*"
+
+// jsonPath is a parsed JSONPath expression, supporting the subset of the
+// syntax made up of field names, array indices and wildcards, e.g.
+// $.items[0].id, $.items[*].id or $['content-type'].
+type jsonPath []string
+
+func parseJSONPath(p string) (jsonPath, error) {
+	if !strings.HasPrefix(p, "$") {
+		return nil, fmt.Errorf("must start with $")
+	}
+
+	var path jsonPath
+	rest := p[1:]
+	for len(rest) > 0 {
+		switch rest[0] {
+		case '.':
+			end := strings.IndexAny(rest[1:], ".[")
+			if end < 0 {
+				end = len(rest) - 1
+			}
+
+			name := rest[1 : end+1]
+			if len(name) == 0 {
+				return nil, fmt.Errorf("empty field name")
+			}
+
+			path = append(path, name)
+			rest = rest[end+1:]
+		case '[':
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated [")
+			}
+
+			selector := rest[1:end]
+			if len(selector) > 1 && (selector[0] == '\'' || selector[0] == '"') && selector[len(selector)-1] == selector[0] {
+				selector = selector[1 : len(selector)-1]
+			} else if _, err := strconv.Atoi(selector); err != nil && selector != jsonPathWildcard {
+				return nil, fmt.Errorf("invalid selector [%s]", selector)
+			}
+
+			path = append(path, selector)
+			rest = rest[end+1:]
+		default:
+			return nil, fmt.Errorf("unexpected %q", rest[0])
+		}
+	}
+
+	return path, nil
+}
+
+// eval returns every value in the document selected by the path.
+func (p jsonPath) eval(doc interface{}) []interface{} {
+	values := []interface{}{doc}
+	for _, selector := range p {
+		var next []interface{}
+		for _, v := range values {
+			switch value := v.(type) {
+			case map[string]interface{}:
+				if selector == jsonPathWildcard {
+					for _, field := range value {
+						next = append(next, field)
+					}
+				} else if field, prs := value[selector]; prs {
+					next = append(next, field)
+				}
+			case []interface{}:
+				if selector == jsonPathWildcard {
+					next = append(next, value...)
+				} else if i, err := strconv.Atoi(selector); err == nil && i >= 0 && i < len(value) {
+					next = append(next, value[i])
+				}
+			}
+		}
+
+		values = next
+	}
+
+	return values
+}
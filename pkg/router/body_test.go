@@ -0,0 +1,166 @@
+package router
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestBodyMatcherShould(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		matcher BodyMatcher
+		body    string
+		match   bool
+	}{
+		{name: "match an exact body", matcher: BodyMatcher{Equals: "ping"}, body: "ping", match: true},
+		{name: "not match a different body", matcher: BodyMatcher{Equals: "ping"}, body: "pong", match: false},
+		{name: "match a regex", matcher: BodyMatcher{Regex: `"type":\s*"order"`}, body: `{"type": "order"}`, match: true},
+		{
+			name:    "match a json subset",
+			matcher: BodyMatcher{JSON: map[string]interface{}{"type": "order", "items": []interface{}{map[string]interface{}{"id": 1}}}},
+			body:    `{"type": "order", "id": 7, "items": [{"id": 1, "qty": 2}]}`,
+			match:   true,
+		},
+		{
+			name:    "not match json missing a field",
+			matcher: BodyMatcher{JSON: map[string]interface{}{"type": "order"}},
+			body:    `{"kind": "order"}`,
+			match:   false,
+		},
+		{
+			name:    "not match json arrays of a different length",
+			matcher: BodyMatcher{JSON: []interface{}{1}},
+			body:    `[1, 2]`,
+			match:   false,
+		},
+		{
+			name:    "match json paths",
+			matcher: BodyMatcher{JSONPath: map[string]Matcher{"$.items[*].sku": {Value: "abc"}, "$.total": {Regex: `^\d+$`}, "$['content-type']": {Present: true}}},
+			body:    `{"items": [{"sku": "xyz"}, {"sku": "abc"}], "total": 12, "content-type": "x"}`,
+			match:   true,
+		},
+		{
+			name:    "match the absence of a json path",
+			matcher: BodyMatcher{JSONPath: map[string]Matcher{"$.items[0].coupon": {Present: true, Not: true}}},
+			body:    `{"items": [{"sku": "abc"}]}`,
+			match:   true,
+		},
+		{name: "not match json paths against a non-json body", matcher: BodyMatcher{JSONPath: map[string]Matcher{"$.a": {Present: true}}}, body: "a=1", match: false},
+		{name: "match form fields", matcher: BodyMatcher{Form: map[string]Matcher{"action": {Value: "buy"}}}, body: "action=buy&qty=1", match: true},
+		{name: "require every condition", matcher: BodyMatcher{Equals: "a=1", Form: map[string]Matcher{"a": {Value: "2"}}}, body: "a=1", match: false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			match, err := tc.matcher.compile()
+			if err != nil {
+				t.Fatalf(errFmt, nil, err)
+			}
+
+			if m := match([]byte(tc.body)); m != tc.match {
+				t.Errorf(errFmt, tc.match, m)
+			}
+		})
+	}
+
+	t.Run("compare json decoded from yaml", func(t *testing.T) {
+		route := Route{}
+		raw := []byte("body:\n  json:\n    type: order\n    items: [{id: 1}]\n")
+		if err := unmarshalStrict(raw, &route); err != nil {
+			t.Fatalf(errFmt, nil, err)
+		}
+
+		match, err := route.Body.compile()
+		if err != nil {
+			t.Fatalf(errFmt, nil, err)
+		}
+
+		if !match([]byte(`{"type": "order", "items": [{"id": 1}]}`)) {
+			t.Errorf(errFmt, true, false)
+		}
+	})
+
+	t.Run("decode json from yaml into json types", func(t *testing.T) {
+		route := Route{}
+		raw := []byte("body:\n  json:\n    type: order\n    items: [{id: 1}]\n")
+		if err := unmarshalStrict(raw, &route); err != nil {
+			t.Fatalf(errFmt, nil, err)
+		}
+
+		expected := map[string]interface{}{"type": "order", "items": []interface{}{map[string]interface{}{"id": float64(1)}}}
+		if !reflect.DeepEqual(expected, route.Body.JSON) {
+			t.Errorf(errFmt, expected, route.Body.JSON)
+		}
+	})
+
+	for _, tc := range []struct {
+		name    string
+		matcher BodyMatcher
+	}{
+		{name: "return an error when no condition is set", matcher: BodyMatcher{}},
+		{name: "return an error on an invalid regex", matcher: BodyMatcher{Regex: "("}},
+		{name: "return an error on an invalid json path", matcher: BodyMatcher{JSONPath: map[string]Matcher{"items": {Present: true}}}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := tc.matcher.validate(); err == nil {
+				t.Errorf(errFmt, "an error", err)
+			}
+		})
+	}
+}
+
+func TestJSONPathShould(t *testing.T) {
+	t.Run("parse fields, indices, wildcards and quoted names", func(t *testing.T) {
+		path, err := parseJSONPath(`$.items[0]['content-type'][*].id`)
+		if err != nil {
+			t.Fatalf(errFmt, nil, err)
+		}
+
+		expected := jsonPath{"items", "0", "content-type", "*", "id"}
+		if !reflect.DeepEqual(expected, path) {
+			t.Errorf(errFmt, expected, path)
+		}
+	})
+}
+
+func TestRouterBodyMatchingShould(t *testing.T) {
+	t.Run("dispatch on the body and leave it readable", func(t *testing.T) {
+		orders := &Route{
+			Path:     "/events",
+			Method:   Methods{"POST"},
+			Body:     &BodyMatcher{JSON: map[string]interface{}{"type": "order"}},
+			Handlers: []Handler{{Weight: 1, ResponseStatus: 201, StaticResponse: "order"}},
+		}
+		refunds := &Route{
+			Path:     "/events",
+			Method:   Methods{"POST"},
+			Body:     &BodyMatcher{JSON: map[string]interface{}{"type": "refund"}},
+			Handlers: []Handler{{Weight: 1, ResponseStatus: 202, StaticResponse: "refund"}},
+		}
+
+		router, err := New([]*Route{orders, refunds})
+		if err != nil {
+			t.Fatalf(errFmt, nil, err)
+		}
+		defer orders.Close()
+		defer refunds.Close()
+
+		body := `{"type": "refund", "id": 1}`
+		req, err := http.NewRequest("POST", "/events", bytes.NewBufferString(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		if rr.Code != 202 {
+			t.Errorf(errFmt, 202, rr.Code)
+		}
+
+		if b, _ := ioutil.ReadAll(req.Body); string(b) != body {
+			t.Errorf(errFmt, body, string(b))
+		}
+	})
+}
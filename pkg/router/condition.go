@@ -0,0 +1,152 @@
+package router
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"net/http"
+	"strings"
+
+	"github.com/leekchan/gtf"
+	"github.com/ncatelli/mockserver/pkg/router/generator"
+)
+
+// Condition describes the requests a handler is selected for. Every condition
+// that is set must be satisfied for a request to match.
+type Condition struct {
+	// RequestHeaders requires the values of each header to satisfy the
+	// corresponding matcher.
+	RequestHeaders map[string]Matcher `yaml:"request_headers,omitempty" json:"request_headers,omitempty" toml:"request_headers,omitempty"`
+
+	// QueryParams requires the values of each query parameter to satisfy the
+	// corresponding matcher.
+	QueryParams map[string]Matcher `yaml:"query_params,omitempty" json:"query_params,omitempty" toml:"query_params,omitempty"`
+
+	// PathVars requires the value of each path variable to satisfy the
+	// corresponding matcher.
+	PathVars map[string]Matcher `yaml:"path_vars,omitempty" json:"path_vars,omitempty" toml:"path_vars,omitempty"`
+
+	// Body requires the request body to satisfy the matcher.
+	Body *BodyMatcher `yaml:"body,omitempty" json:"body,omitempty" toml:"body,omitempty"`
+
+	// Expression is a template, rendered with the same variables and
+	// functions as a response body, that must render to "true".
+	Expression string `yaml:"expression,omitempty" json:"expression,omitempty" toml:"expression,omitempty"`
+}
+
+// compile returns a function reporting whether a request satisfies the
+// condition.
+func (c *Condition) compile() (func(*http.Request) bool, error) {
+	var conditions []func(*http.Request) bool
+
+	for k, m := range c.RequestHeaders {
+		key := k
+		match, err := m.compile()
+		if err != nil {
+			return nil, fmt.Errorf("request_headers %s: %v", k, err)
+		}
+
+		conditions = append(conditions, func(r *http.Request) bool {
+			return match(r.Header.Values(key))
+		})
+	}
+
+	for k, m := range c.QueryParams {
+		key := k
+		match, err := m.compile()
+		if err != nil {
+			return nil, fmt.Errorf("query_params %s: %v", k, err)
+		}
+
+		conditions = append(conditions, func(r *http.Request) bool {
+			return match(r.URL.Query()[key])
+		})
+	}
+
+	for k, m := range c.PathVars {
+		key := k
+		match, err := m.compile()
+		if err != nil {
+			return nil, fmt.Errorf("path_vars %s: %v", k, err)
+		}
+
+		conditions = append(conditions, func(r *http.Request) bool {
+			pathVars, _ := splitVars(r)
+			if v, prs := pathVars[key]; prs {
+				return match([]string{v})
+			}
+
+			return match(nil)
+		})
+	}
+
+	if c.Body != nil {
+		match, err := c.Body.compile()
+		if err != nil {
+			return nil, fmt.Errorf("body: %v", err)
+		}
+
+		conditions = append(conditions, func(r *http.Request) bool {
+			body, err := readBody(r)
+			return err == nil && match(body)
+		})
+	}
+
+	if len(c.Expression) > 0 {
+		t, err := template.New("").Funcs(gtf.GtfFuncMap).Funcs(generator.PluginsFuncMap()).Parse(c.Expression)
+		if err != nil {
+			return nil, fmt.Errorf("expression: %v", err)
+		}
+
+		conditions = append(conditions, func(r *http.Request) bool {
+			var out bytes.Buffer
+			if err := t.Execute(&out, newTemplateVariables(r)); err != nil {
+				return false
+			}
+
+			return strings.TrimSpace(out.String()) == "true"
+		})
+	}
+
+	if len(conditions) == 0 {
+		return nil, fmt.Errorf("at least one of request_headers, query_params, path_vars, body or expression must be set")
+	}
+
+	return func(r *http.Request) bool {
+		for _, c := range conditions {
+			if !c(r) {
+				return false
+			}
+		}
+
+		return true
+	}, nil
+}
+
+// validate returns an error describing why the condition is invalid, or nil
+// if it is valid.
+func (c *Condition) validate() error {
+	for _, field := range []struct {
+		name     string
+		matchers map[string]Matcher
+	}{
+		{name: "request_headers", matchers: c.RequestHeaders},
+		{name: "query_params", matchers: c.QueryParams},
+		{name: "path_vars", matchers: c.PathVars},
+	} {
+		for _, k := range sortedKeys(field.matchers) {
+			if err := field.matchers[k].validate(); err != nil {
+				return fmt.Errorf("%s %s: %v", field.name, k, err)
+			}
+		}
+	}
+
+	if c.Body != nil {
+		if err := c.Body.validate(); err != nil {
+			return fmt.Errorf("body: %v", err)
+		}
+	}
+
+	_, err := c.compile()
+	return err
+}
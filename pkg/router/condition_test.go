@@ -0,0 +1,186 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ncatelli/mockserver/pkg/state"
+)
+
+func TestConditionShould(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		condition Condition
+		header    string
+		url       string
+		body      string
+		match     bool
+	}{
+		{name: "match a header", condition: Condition{RequestHeaders: map[string]Matcher{"X-Role": {Value: "admin"}}}, header: "admin", url: "/", match: true},
+		{name: "not match a header", condition: Condition{RequestHeaders: map[string]Matcher{"X-Role": {Value: "admin"}}}, header: "user", url: "/", match: false},
+		{name: "match a query parameter", condition: Condition{QueryParams: map[string]Matcher{"debug": {Present: true}}}, url: "/?debug=1", match: true},
+		{name: "match a body", condition: Condition{Body: &BodyMatcher{JSON: map[string]interface{}{"type": "order"}}}, url: "/", body: `{"type": "order"}`, match: true},
+		{name: "match an expression rendering true", condition: Condition{Expression: `{{ eq (.Request.URL.Query.Get "page") "2" }}`}, url: "/?page=2", match: true},
+		{name: "not match an expression rendering anything else", condition: Condition{Expression: `{{ .Request.URL.Query.Get "page" }}`}, url: "/?page=2", match: false},
+		{name: "require every condition to match", condition: Condition{QueryParams: map[string]Matcher{"debug": {Present: true}}, RequestHeaders: map[string]Matcher{"X-Role": {Value: "admin"}}}, url: "/?debug=1", match: false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			match, err := tc.condition.compile()
+			if err != nil {
+				t.Fatalf(errFmt, nil, err)
+			}
+
+			req := httptest.NewRequest("POST", tc.url, strings.NewReader(tc.body))
+			if len(tc.header) > 0 {
+				req.Header.Set("X-Role", tc.header)
+			}
+
+			if m := match(req); m != tc.match {
+				t.Errorf(errFmt, tc.match, m)
+			}
+		})
+	}
+
+	for _, tc := range []struct {
+		name      string
+		condition Condition
+	}{
+		{name: "return an error when no condition is set", condition: Condition{}},
+		{name: "return an error on an invalid matcher", condition: Condition{PathVars: map[string]Matcher{"id": {}}}},
+		{name: "return an error on an invalid expression", condition: Condition{Expression: "{{ .Request"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := tc.condition.validate(); err == nil {
+				t.Errorf(errFmt, "an error", err)
+			}
+		})
+	}
+}
+
+func TestRouterConditionalHandlersShould(t *testing.T) {
+	routes := []*Route{
+		{
+			Path:   "/users/{id}",
+			Method: Methods{"GET"},
+			Handlers: []Handler{
+				{When: &Condition{PathVars: map[string]Matcher{"id": {Regex: "^[12]$"}}}, ResponseStatus: 200},
+				{When: &Condition{RequestHeaders: map[string]Matcher{"X-Role": {Value: "admin"}}}, ResponseStatus: 202},
+			},
+		},
+		{
+			Path:   "/orders/{id}",
+			Method: Methods{"GET"},
+			Handlers: []Handler{
+				{When: &Condition{PathVars: map[string]Matcher{"id": {Value: "1"}}}, ResponseStatus: 200},
+				{Weight: 1, ResponseStatus: 410},
+			},
+		},
+	}
+
+	router, err := New(routes)
+	if err != nil {
+		t.Fatalf(errFmt, nil, err)
+	}
+	defer func() {
+		for _, r := range routes {
+			r.Close()
+		}
+	}()
+
+	for _, tc := range []struct {
+		name     string
+		path     string
+		role     string
+		expected int
+	}{
+		{name: "serve the first handler whose condition matches", path: "/users/1", role: "admin", expected: 200},
+		{name: "try each conditional handler in order", path: "/users/3", role: "admin", expected: 202},
+		{name: "respond with a 404 when no condition matches", path: "/users/3", expected: 404},
+		{name: "fall back to weighted handlers when no condition matches", path: "/orders/2", expected: 410},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", tc.path, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(tc.role) > 0 {
+				req.Header.Set("X-Role", tc.role)
+			}
+
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+			if rr.Code != tc.expected {
+				t.Errorf(errFmt, tc.expected, rr.Code)
+			}
+		})
+	}
+}
+
+func TestRouterScenariosShould(t *testing.T) {
+	state.DefaultScenarios.Reset("orders")
+	t.Cleanup(func() { state.DefaultScenarios.Reset("orders") })
+
+	routes := []*Route{
+		{
+			Path:   "/orders",
+			Method: Methods{"POST"},
+			Handlers: []Handler{
+				{Weight: 1, ResponseStatus: 201, Scenario: "orders", NewState: "created"},
+			},
+		},
+		{
+			Path:   "/orders/1",
+			Method: Methods{"GET"},
+			Handlers: []Handler{
+				{ResponseStatus: 200, Scenario: "orders", RequiredState: "created"},
+				{Weight: 1, ResponseStatus: 404},
+			},
+		},
+	}
+
+	router, err := New(routes)
+	if err != nil {
+		t.Fatalf(errFmt, nil, err)
+	}
+	defer func() {
+		for _, r := range routes {
+			r.Close()
+		}
+	}()
+
+	serve := func(method, path string) int {
+		req, err := http.NewRequest(method, path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr.Code
+	}
+
+	t.Run("not select handlers requiring a different state", func(t *testing.T) {
+		if code := serve("GET", "/orders/1"); code != 404 {
+			t.Errorf(errFmt, 404, code)
+		}
+	})
+
+	t.Run("transition the scenario when a handler is served", func(t *testing.T) {
+		if code := serve("POST", "/orders"); code != 201 {
+			t.Errorf(errFmt, 201, code)
+		}
+
+		if s := state.DefaultScenarios.State("orders"); s != "created" {
+			t.Errorf(errFmt, "created", s)
+		}
+	})
+
+	t.Run("select handlers requiring the current state", func(t *testing.T) {
+		if code := serve("GET", "/orders/1"); code != 200 {
+			t.Errorf(errFmt, 200, code)
+		}
+	})
+}
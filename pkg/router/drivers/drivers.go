@@ -0,0 +1,79 @@
+package drivers
+
+import (
+	"io"
+	"net/url"
+
+	"github.com/ncatelli/mockserver/pkg/router"
+	"github.com/ncatelli/mockserver/pkg/router/drivers/json"
+	"github.com/ncatelli/mockserver/pkg/router/drivers/loader"
+	"github.com/ncatelli/mockserver/pkg/router/drivers/simple"
+	"github.com/ncatelli/mockserver/pkg/router/drivers/toml"
+)
+
+var (
+	drivers = make(map[string]func() Driver)
+)
+
+func init() {
+	Register("simple", func() Driver { return simple.New() })
+	Register("yaml", func() Driver { return simple.New() })
+	Register("json", func() Driver { return json.New() })
+	Register("toml", func() Driver { return toml.New() })
+}
+
+// Driver defines the necessary functions to configure a route source and load
+// routes from it. LoadFromPath is passed the configured path verbatim, so
+// drivers that aren't backed by files are free to interpret it, for example
+// as a connection string.
+type Driver interface {
+	Init(map[string]string) error
+	Load(io.Reader) ([]*router.Route, error)
+	LoadFromPath(string) ([]*router.Route, error)
+}
+
+// FileDriver is implemented by drivers that load routes from files, reporting
+// which file extensions are loaded when pointed at a directory.
+type FileDriver interface {
+	Driver
+	Extensions() []string
+}
+
+// URLDriver is implemented by drivers that resolve relative references
+// within a document fetched from a URL against the URL.
+type URLDriver interface {
+	Driver
+	LoadFromURL(*url.URL, io.Reader) ([]*router.Route, error)
+}
+
+// Decoder is implemented by drivers that can read the entries of a route
+// configuration as written, without expanding environment variables or
+// includes, or resolving relative paths, allowing route configurations to be
+// converted between drivers.
+type Decoder interface {
+	Decode(io.Reader) ([]loader.Entry, error)
+	DecodeFromPath(string) ([]loader.Entry, error)
+}
+
+// Encoder is implemented by drivers that can write the entries of a route
+// configuration in their own format, allowing route configurations to be
+// converted between drivers.
+type Encoder interface {
+	Encode(io.Writer, []loader.Entry) error
+}
+
+// Register makes a driver available by the provided id. If Register is called
+// twice with the same id, the latter registration replaces the former.
+func Register(id string, factory func() Driver) {
+	drivers[id] = factory
+}
+
+// Lookup takes an id and attempts to return a new instance of the
+// corresponding driver. If the driver is undefined nil is returned.
+func Lookup(id string) Driver {
+	if f, prs := drivers[id]; prs == true {
+		return f()
+	}
+
+	return nil
+}
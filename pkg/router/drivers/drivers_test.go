@@ -0,0 +1,115 @@
+package drivers
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"testing"
+
+	"github.com/ncatelli/mockserver/pkg/router"
+	"github.com/ncatelli/mockserver/pkg/router/drivers/loader"
+)
+
+const (
+	errFmt string = "want %v, got %v"
+)
+
+type testDriver struct{}
+
+func (td *testDriver) Init(conf map[string]string) error {
+	return nil
+}
+
+func (td *testDriver) Load(data io.Reader) ([]*router.Route, error) {
+	return nil, nil
+}
+
+func (td *testDriver) LoadFromPath(path string) ([]*router.Route, error) {
+	return nil, nil
+}
+
+func TestDriverLookupShould(t *testing.T) {
+	t.Run("return a driver if it has been registered", func(t *testing.T) {
+		Register("test", func() Driver { return &testDriver{} })
+		defer delete(drivers, "test")
+
+		if d := Lookup("test"); d == nil {
+			t.Errorf(errFmt, &testDriver{}, d)
+		}
+	})
+
+	t.Run("return a new instance on each lookup", func(t *testing.T) {
+		if Lookup("simple") == Lookup("simple") {
+			t.Errorf(errFmt, "distinct instances", "the same instance")
+		}
+	})
+
+	t.Run("return file drivers for each built-in format", func(t *testing.T) {
+		for _, id := range []string{"simple", "yaml", "json", "toml"} {
+			if _, ok := Lookup(id).(FileDriver); !ok {
+				t.Errorf(errFmt, id, nil)
+			}
+		}
+	})
+
+	t.Run("return nil if the driver isn't registered", func(t *testing.T) {
+		if d := Lookup("test_driver_shouldn't_exist"); d != nil {
+			t.Errorf(errFmt, nil, d)
+		}
+	})
+}
+
+func TestEncodersShould(t *testing.T) {
+	entries := []loader.Entry{
+		{Include: "shared/auth.yaml"},
+		{Route: router.Route{
+			Path:           "/test",
+			Method:         router.Methods{"GET"},
+			RequestHeaders: map[string]router.Matcher{"accept": {Value: "application/json"}, "authorization": {Regex: "^Bearer ${TOKEN}"}, "x-debug": {Present: true, Not: true}},
+			Middleware:     map[string]map[string]string{"latency": {"min": "10", "max": "20"}},
+			Handlers: []router.Handler{
+				{Weight: 1, StaticResponse: `{"resp": "Ok"}`, ResponseStatus: 200},
+				{When: &router.Condition{QueryParams: map[string]router.Matcher{"id": {Regex: "^[0-9]+$"}}, Expression: "true"}, ResponseStatus: 202},
+			},
+		}},
+		{Route: router.Route{
+			Path:     "/multiple",
+			Method:   router.Methods{"PUT", "PATCH"},
+			Body:     &router.BodyMatcher{JSON: map[string]interface{}{"type": "order"}},
+			Handlers: []router.Handler{{Weight: 1, ResponsePath: "bodies/multiple.json", ResponseStatus: 204}},
+		}},
+		{Route: router.Route{
+			Path:     "/users",
+			Resource: &router.Resource{Name: "users", Seed: "seeds/users.json"},
+		}},
+	}
+
+	for _, id := range []string{"simple", "json", "toml"} {
+		id := id
+		t.Run("round trip entries through the "+id+" driver as written", func(t *testing.T) {
+			d := Lookup(id)
+			enc, ok := d.(Encoder)
+			if !ok {
+				t.Fatalf(errFmt, "an Encoder", d)
+			}
+
+			var buf bytes.Buffer
+			if err := enc.Encode(&buf, entries); err != nil {
+				t.Fatalf(errFmt, nil, err)
+			}
+
+			decoded, err := d.(Decoder).Decode(&buf)
+			if err != nil {
+				t.Fatalf(errFmt, nil, err)
+			}
+
+			for i := range decoded {
+				decoded[i].Line = 0
+			}
+
+			if !reflect.DeepEqual(entries, decoded) {
+				t.Errorf(errFmt, entries, decoded)
+			}
+		})
+	}
+}
@@ -0,0 +1,13 @@
+package drivers
+
+import "fmt"
+
+// ErrUndefinedDriver represents lookup against a driver that has yet to be
+// defined has failed.
+type ErrUndefinedDriver struct {
+	ID string
+}
+
+func (e ErrUndefinedDriver) Error() string {
+	return fmt.Sprintf("the driver %s is undefined", e.ID)
+}
@@ -0,0 +1,132 @@
+package json
+
+import (
+	"bytes"
+	stdjson "encoding/json"
+	"io"
+
+	"github.com/ncatelli/mockserver/pkg/router"
+	"github.com/ncatelli/mockserver/pkg/router/drivers/loader"
+)
+
+// Format describes the json configuration format. A json document is an array
+// of routes and include directives, mirroring the simple yaml format. Unknown
+// fields are rejected.
+var Format = loader.Format{
+	Extensions: []string{".json"},
+	Decode:     decode,
+	Escape:     escape,
+}
+
+// escape escapes the value of a reference within a string so that it can't
+// end the string. Values substituted outside of a string, such as numbers,
+// are left unmodified.
+func escape(b []byte, start, end int, value string) (string, error) {
+	if inString(b[:start]) {
+		return loader.EscapeString(value), nil
+	}
+
+	return value, nil
+}
+
+// inString returns true if the end of b falls within a string.
+func inString(b []byte) bool {
+	in := false
+	for i := 0; i < len(b); i++ {
+		switch {
+		case in && b[i] == '\\':
+			i++
+		case b[i] == '"':
+			in = !in
+		}
+	}
+
+	return in
+}
+
+// decode streams the elements of the top-level array so that the line each
+// entry starts at can be recorded.
+func decode(b []byte) ([]loader.Entry, error) {
+	entries := make([]loader.Entry, 0)
+	dec := stdjson.NewDecoder(bytes.NewReader(b))
+	dec.DisallowUnknownFields()
+
+	tok, err := dec.Token()
+	if err != nil {
+		return entries, decodeError(b, 0, err)
+	} else if delim, ok := tok.(stdjson.Delim); !ok || delim != '[' {
+		return entries, router.ErrValidation{
+			{Line: 1, Reason: "document must be an array of routes"},
+		}
+	}
+
+	for dec.More() {
+		start := elementStart(b, dec.InputOffset())
+
+		var e loader.Entry
+		if err := dec.Decode(&e); err != nil {
+			return entries, decodeError(b, start, err)
+		}
+
+		e.Line = lineAt(b, start)
+		entries = append(entries, e)
+	}
+
+	if _, err := dec.Token(); err != nil {
+		return entries, decodeError(b, dec.InputOffset(), err)
+	}
+
+	return entries, nil
+}
+
+// decodeError converts a json error into an ErrValidation, using the offset
+// reported by the error when available and the passed offset otherwise.
+func decodeError(b []byte, offset int64, err error) error {
+	switch e := err.(type) {
+	case *stdjson.SyntaxError:
+		offset = e.Offset
+	case *stdjson.UnmarshalTypeError:
+		offset = e.Offset
+	}
+
+	return router.ErrValidation{
+		{Line: lineAt(b, offset), Reason: err.Error()},
+	}
+}
+
+// elementStart skips any whitespace and separators preceding the array
+// element at the passed offset.
+func elementStart(b []byte, offset int64) int64 {
+	for offset < int64(len(b)) && bytes.IndexByte([]byte(" \t\r\n,"), b[offset]) >= 0 {
+		offset++
+	}
+
+	return offset
+}
+
+// lineAt returns the 1-indexed line that the passed offset falls on.
+func lineAt(b []byte, offset int64) int {
+	if offset > int64(len(b)) {
+		offset = int64(len(b))
+	}
+
+	return bytes.Count(b[:offset], []byte("\n")) + 1
+}
+
+// Driver loads routes from json documents using the embedded loader.Loader,
+// adding only how the documents are encoded.
+type Driver struct {
+	loader.Loader
+}
+
+// New returns a Driver for json documents with no base directory.
+func New() *Driver {
+	return &Driver{Loader: loader.Loader{Format: Format}}
+}
+
+// Encode writes entries to w as an indented json document.
+func (d Driver) Encode(w io.Writer, entries []loader.Entry) error {
+	enc := stdjson.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(entries)
+}
@@ -0,0 +1,139 @@
+package json
+
+import (
+	"bytes"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/ncatelli/mockserver/pkg/router"
+)
+
+const (
+	goodConfigPath string = "test_fixtures/good.json"
+	errFmt         string = "want %v, got %v"
+)
+
+var (
+	badConfig     = []byte(`[{"path": `)
+	includeConfig = []byte(`[{"include": "test_fixtures/good.json"}]`)
+)
+
+var expectedRoutes = []*router.Route{
+	&router.Route{
+		Path:   "/test/weighted/errors",
+		Method: router.Methods{"GET"},
+		Handlers: []router.Handler{
+			router.Handler{
+				Weight: 2,
+				ResponseHeaders: map[string]string{
+					"content-type": "application/json",
+				},
+				StaticResponse: "{\"resp\": \"Ok\"}",
+				ResponseStatus: 200,
+			},
+			router.Handler{
+				Weight: 1,
+				ResponseHeaders: map[string]string{
+					"content-type": "text/plain",
+				},
+				ResponseStatus: 500,
+			},
+		},
+		Source: goodConfigPath,
+		Line:   2,
+	},
+}
+
+func TestLoadFromFileShould(t *testing.T) {
+	t.Run("load a valid configuration", func(t *testing.T) {
+		routes, err := New().LoadFromFile(goodConfigPath)
+		if err != nil {
+			t.Errorf(errFmt, expectedRoutes, err)
+		} else if !reflect.DeepEqual(routes, expectedRoutes) {
+			t.Errorf(errFmt, expectedRoutes, routes)
+		}
+	})
+
+	t.Run("load a directory of configurations", func(t *testing.T) {
+		routes, err := New().LoadFromPath("test_fixtures")
+		if err != nil {
+			t.Errorf(errFmt, expectedRoutes, err)
+		} else if !reflect.DeepEqual(routes, expectedRoutes) {
+			t.Errorf(errFmt, expectedRoutes, routes)
+		}
+	})
+}
+
+func TestLoadShould(t *testing.T) {
+	t.Run("expand include directives", func(t *testing.T) {
+		routes, err := New().Load(bytes.NewReader(includeConfig))
+		if err != nil {
+			t.Errorf(errFmt, expectedRoutes, err)
+		} else if !reflect.DeepEqual(routes, expectedRoutes) {
+			t.Errorf(errFmt, expectedRoutes, routes)
+		}
+	})
+
+	t.Run("return an error on a non-valid configuration", func(t *testing.T) {
+		_, err := New().Load(bytes.NewReader(badConfig))
+		if err == nil {
+			t.Errorf(errFmt, "an error", err)
+		}
+	})
+}
+
+func TestStrictDecodingShould(t *testing.T) {
+	t.Run("report unknown fields with the line they occur at", func(t *testing.T) {
+		config := []byte(`[
+  {"path": "/ok", "method": "GET", "handlers": [{"weight": 1, "response_status": 200}]},
+  {
+    "path": "/typo",
+    "method": "GET",
+    "handlers": [{"weight": 1, "respone_status": 200}]
+  }
+]`)
+
+		_, err := New().Load(bytes.NewReader(config))
+
+		problems, ok := err.(router.ErrValidation)
+		if !ok || len(problems) != 1 {
+			t.Fatalf(errFmt, router.ErrValidation{}, err)
+		}
+
+		expected := router.ErrInvalidConfig{
+			Line:   3,
+			Reason: `json: unknown field "respone_status"`,
+		}
+		if problems[0] != expected {
+			t.Errorf(errFmt, expected, problems[0])
+		}
+	})
+}
+
+func TestEnvironmentInterpolationShould(t *testing.T) {
+	os.Setenv("MOCKSERVER_TEST_TOKEN", `ab"c\`)
+	defer os.Unsetenv("MOCKSERVER_TEST_TOKEN")
+
+	t.Run("escape values substituted within strings", func(t *testing.T) {
+		config := []byte(`[{
+  "path": "/token",
+  "method": "GET",
+  "handlers": [{"weight": ${MOCKSERVER_TEST_WEIGHT:-1}, "static_response": "${MOCKSERVER_TEST_TOKEN}", "response_status": 200}]
+}]`)
+
+		routes, err := New().Load(bytes.NewReader(config))
+		if err != nil {
+			t.Fatalf(errFmt, nil, err)
+		}
+
+		h := routes[0].Handlers[0]
+		if h.StaticResponse != `ab"c\` {
+			t.Errorf(errFmt, `ab"c\`, h.StaticResponse)
+		}
+
+		if h.Weight != 1 {
+			t.Errorf(errFmt, 1, h.Weight)
+		}
+	})
+}
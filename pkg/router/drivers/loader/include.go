@@ -0,0 +1,330 @@
+package loader
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"time"
+
+	"github.com/ncatelli/mockserver/pkg/router"
+)
+
+// IncludeTimeout is the maximum time to wait on a response when fetching an
+// included document from a URL.
+var IncludeTimeout = 10 * time.Second
+
+// ErrIncludeCycle represents a document that directly or indirectly includes
+// itself.
+type ErrIncludeCycle struct {
+	Location string
+}
+
+func (e ErrIncludeCycle) Error() string {
+	return fmt.Sprintf("include cycle detected at %s", e.Location)
+}
+
+// ErrInvalidInclude represents an include directive that also defines route
+// fields.
+type ErrInvalidInclude struct {
+	Include string
+	Source  string
+	Line    int
+}
+
+func (e ErrInvalidInclude) Error() string {
+	return router.ErrInvalidConfig{
+		Source: e.Source,
+		Line:   e.Line,
+		Reason: fmt.Sprintf("include of %s must not define any route fields", e.Include),
+	}.Error()
+}
+
+// ErrUnexpectedIncludeStatus represents a non-2xx response while fetching an
+// included document from a URL.
+type ErrUnexpectedIncludeStatus struct {
+	URL        string
+	StatusCode int
+}
+
+func (e ErrUnexpectedIncludeStatus) Error() string {
+	return fmt.Sprintf("fetching include %s returned unexpected status %d", e.URL, e.StatusCode)
+}
+
+// origin describes where a document was loaded from so that relative
+// references within it can be resolved and problems within it can be
+// located. A document loaded from a reader has neither a source, a directory
+// nor a URL and resolves relative references against the working directory.
+type origin struct {
+	source string
+	dir    string
+	url    *url.URL
+
+	// includer is the origin of the document that included this one, or nil
+	// if the document was loaded directly.
+	includer *origin
+}
+
+// session tracks the documents currently being loaded by a Loader in order
+// to detect include cycles.
+type session struct {
+	Loader
+	loading map[string]bool
+}
+
+func newSession(l Loader) *session {
+	return &session{
+		Loader:  l,
+		loading: make(map[string]bool),
+	}
+}
+
+// loadFile reads and parses a document from a file, included by the document
+// at includer if it isn't nil.
+func (s *session) loadFile(path string, includer *origin) ([]*router.Route, error) {
+	path = filepath.Clean(path)
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.loading[abs] {
+		return nil, ErrIncludeCycle{Location: path}
+	}
+	s.loading[abs] = true
+	defer delete(s.loading, abs)
+
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.parse(b, origin{source: path, dir: filepath.Dir(path), includer: includer})
+}
+
+// loadURL fetches and parses a document from a URL, included by the document
+// at includer.
+func (s *session) loadURL(u *url.URL, includer *origin) ([]*router.Route, error) {
+	location := u.String()
+	if s.loading[location] {
+		return nil, ErrIncludeCycle{Location: location}
+	}
+	s.loading[location] = true
+	defer delete(s.loading, location)
+
+	client := &http.Client{Timeout: IncludeTimeout}
+	resp, err := client.Get(location)
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, ErrUnexpectedIncludeStatus{URL: location, StatusCode: resp.StatusCode}
+	}
+
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.parse(b, origin{source: location, url: u, includer: includer})
+}
+
+// parseURL parses a document that has already been fetched from a URL, so
+// that relative includes within it are resolved against the URL.
+func (s *session) parseURL(b []byte, u *url.URL) ([]*router.Route, error) {
+	location := u.String()
+	s.loading[location] = true
+	defer delete(s.loading, location)
+
+	return s.parse(b, origin{source: location, url: u})
+}
+
+// parse decodes a document, expanding any environment variable references
+// and include directives in place. Relative response paths are resolved
+// against the Loader's base directory if set, otherwise against the
+// directory of the including file for an included document, or of the
+// document itself if it was loaded directly from a file. Each route records
+// the source and line it was declared at.
+func (s *session) parse(b []byte, o origin) ([]*router.Route, error) {
+	routes := make([]*router.Route, 0)
+	b, err := interpolate(b, s.Format.Escape)
+	if err != nil {
+		return routes, locate(err, o.source)
+	}
+
+	entries, err := s.Format.Decode(b)
+	if err != nil {
+		return routes, locate(err, o.source)
+	}
+
+	for _, e := range entries {
+		if len(e.Include) > 0 {
+			if e.definesRoute() {
+				return routes, ErrInvalidInclude{Include: e.Include, Source: o.source, Line: e.Line}
+			}
+
+			r, err := s.include(e.Include, o)
+			if err != nil {
+				return routes, fmt.Errorf("%sinclude %s: %w", prefix(o.source, e.Line), e.Include, err)
+			}
+
+			routes = append(routes, r...)
+			continue
+		}
+
+		route := e.Route
+		s.prepare(&route, o)
+		routes = append(routes, &route)
+	}
+
+	return routes, nil
+}
+
+// prepare records the source of a route and resolves the response path of
+// each of its handlers and the seed of its resource. Whether the resolved
+// files exist is left to router.Validate, so that it can report them along
+// with every other problem. The child routes of a group are prepared in
+// turn, taking the group's line when their own isn't known.
+func (s *session) prepare(route *router.Route, o origin) {
+	route.Source = o.source
+	if route.Resource != nil {
+		route.Resource.Seed = resolvePath(s.dir(o), route.Resource.Seed)
+	}
+
+	for i := range route.Handlers {
+		route.Handlers[i].ResponsePath = resolvePath(s.dir(o), route.Handlers[i].ResponsePath)
+	}
+
+	for _, child := range route.Routes {
+		if child.Line == 0 {
+			child.Line = route.Line
+		}
+
+		s.prepare(child, o)
+	}
+}
+
+// include loads the document referenced by an include directive. Targets may
+// be an absolute URL, or a path, directory or glob pattern that is resolved
+// relative to the including document.
+func (s *session) include(target string, o origin) ([]*router.Route, error) {
+	u, err := url.Parse(target)
+	if err == nil && (u.Scheme == "http" || u.Scheme == "https") {
+		return s.loadURL(u, &o)
+	} else if err == nil && o.url != nil {
+		return s.loadURL(o.url.ResolveReference(u), &o)
+	}
+
+	files, err := s.ConfigFiles(resolvePath(o.dir, target))
+	if err != nil {
+		return nil, err
+	}
+
+	routes := make([]*router.Route, 0)
+	errs := make([]error, 0)
+	for _, f := range files {
+		r, err := s.loadFile(f, &o)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		routes = append(routes, r...)
+	}
+
+	if err := joinErrors(errs); err != nil {
+		return nil, err
+	}
+
+	return routes, nil
+}
+
+// dir returns the directory relative response paths within a document are
+// resolved against. Paths within an included document are resolved against
+// the directory of the including document.
+func (s *session) dir(o origin) string {
+	if len(s.BaseDir) > 0 {
+		return s.BaseDir
+	} else if o.includer != nil {
+		return o.includer.dir
+	}
+
+	return o.dir
+}
+
+// locate records the source a decoding error was found in. Errors that
+// don't describe a location within the document are prefixed with the
+// source instead.
+func locate(err error, source string) error {
+	if len(source) == 0 {
+		return err
+	}
+
+	switch e := err.(type) {
+	case router.ErrValidation:
+		for i := range e {
+			e[i].Source = source
+		}
+
+		return e
+	case router.ErrInvalidConfig:
+		e.Source = source
+		return e
+	}
+
+	return fmt.Errorf("%s: %w", source, err)
+}
+
+// joinErrors combines the errors found while loading each of several files
+// into one, so that a problem in one file doesn't hide those in the others.
+// A single error is returned unmodified, while several are flattened into an
+// ErrValidation listing every problem. nil is returned if there are none.
+func joinErrors(errs []error) error {
+	switch len(errs) {
+	case 0:
+		return nil
+	case 1:
+		return errs[0]
+	}
+
+	var problems router.ErrValidation
+	for _, err := range errs {
+		var v router.ErrValidation
+		var c router.ErrInvalidConfig
+		if errors.As(err, &v) {
+			problems = append(problems, v...)
+		} else if errors.As(err, &c) {
+			problems = append(problems, c)
+		} else {
+			problems = append(problems, router.ErrInvalidConfig{Reason: err.Error()})
+		}
+	}
+
+	return problems
+}
+
+// prefix formats a location within a document as an error message prefix,
+// returning an empty string when the location is unknown.
+func prefix(source string, line int) string {
+	if len(source) == 0 {
+		return ""
+	} else if line > 0 {
+		return fmt.Sprintf("%s:%d: ", source, line)
+	}
+
+	return source + ": "
+}
+
+// resolvePath joins a relative path to the passed directory, returning
+// absolute and empty paths unmodified.
+func resolvePath(dir, path string) string {
+	if len(path) == 0 || filepath.IsAbs(path) {
+		return path
+	}
+
+	return filepath.Join(dir, path)
+}
@@ -0,0 +1,76 @@
+package loader
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/ncatelli/mockserver/pkg/router"
+)
+
+// variablePattern matches an escaped "$${" or a "${VAR}" or "${VAR:-default}"
+// reference, capturing the variable name and optional default.
+var variablePattern = regexp.MustCompile(`\$\$\{|\$\{([A-Za-z_][A-Za-z0-9_]*)(:-([^}]*))?\}`)
+
+// interpolate expands environment variable references in a document prior to
+// unmarshalling. A "${VAR}" reference is replaced with the value of VAR, or an
+// empty string if it is unset. A "${VAR:-default}" reference is replaced with
+// default if VAR is unset or empty. A literal "${" may be written as "$${".
+// Each value is passed through escape, when set, along with the document and
+// the offsets of the reference within it so that it can be escaped for the
+// position it is substituted at.
+func interpolate(b []byte, escape func(b []byte, start, end int, value string) (string, error)) ([]byte, error) {
+	var out bytes.Buffer
+	last := 0
+	for _, m := range variablePattern.FindAllSubmatchIndex(b, -1) {
+		start, end := m[0], m[1]
+		out.Write(b[last:start])
+		last = end
+
+		if string(b[start:end]) == "$${" {
+			out.WriteString("${")
+			continue
+		}
+
+		name := string(b[m[2]:m[3]])
+		value, prs := os.LookupEnv(name)
+		if m[4] >= 0 && (!prs || len(value) == 0) {
+			value = string(b[m[6]:m[7]])
+		}
+
+		if escape != nil {
+			escaped, err := escape(b, start, end, value)
+			if err != nil {
+				return nil, router.ErrInvalidConfig{
+					Line:   bytes.Count(b[:start], []byte("\n")) + 1,
+					Reason: fmt.Sprintf("value of %s %v", name, err),
+				}
+			}
+
+			value = escaped
+		}
+
+		out.WriteString(value)
+	}
+	out.Write(b[last:])
+
+	return out.Bytes(), nil
+}
+
+// EscapeString escapes a value for substitution within a double-quoted
+// string. The escapes used are common to json, toml basic strings and yaml
+// double-quoted scalars.
+func EscapeString(value string) string {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+
+	// encoding a string can't fail.
+	enc.Encode(value)
+
+	quoted := strings.TrimSuffix(buf.String(), "\n")
+	return quoted[1 : len(quoted)-1]
+}
@@ -0,0 +1,91 @@
+package loader
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/ncatelli/mockserver/pkg/router"
+)
+
+const (
+	errFmt string = "want %v, got %v"
+)
+
+func TestInterpolateShould(t *testing.T) {
+	os.Setenv("MOCKSERVER_TEST_HOST", "upstream.example.com")
+	os.Setenv("MOCKSERVER_TEST_EMPTY", "")
+	defer os.Unsetenv("MOCKSERVER_TEST_HOST")
+	defer os.Unsetenv("MOCKSERVER_TEST_EMPTY")
+
+	for _, tc := range []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{name: "expand a set variable", input: "http://${MOCKSERVER_TEST_HOST}/", expected: "http://upstream.example.com/"},
+		{name: "expand an unset variable to an empty string", input: "[${MOCKSERVER_TEST_UNSET}]", expected: "[]"},
+		{name: "use the default for an unset variable", input: "${MOCKSERVER_TEST_UNSET:-8080}", expected: "8080"},
+		{name: "use the default for an empty variable", input: "${MOCKSERVER_TEST_EMPTY:-8080}", expected: "8080"},
+		{name: "prefer a set variable over the default", input: "${MOCKSERVER_TEST_HOST:-localhost}", expected: "upstream.example.com"},
+		{name: "unescape an escaped reference", input: "$${MOCKSERVER_TEST_HOST}", expected: "${MOCKSERVER_TEST_HOST}"},
+		{name: "leave bare variables and templates untouched", input: "$MOCKSERVER_TEST_HOST {{ .PathVars.id }}", expected: "$MOCKSERVER_TEST_HOST {{ .PathVars.id }}"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			out, err := interpolate([]byte(tc.input), nil)
+			if err != nil {
+				t.Fatalf(errFmt, nil, err)
+			}
+
+			if string(out) != tc.expected {
+				t.Errorf(errFmt, tc.expected, string(out))
+			}
+		})
+	}
+
+	t.Run("escape values with the offsets of each reference", func(t *testing.T) {
+		input := "a ${MOCKSERVER_TEST_HOST} $${MOCKSERVER_TEST_HOST}"
+		escape := func(b []byte, start, end int, value string) (string, error) {
+			return fmt.Sprintf("<%s:%d-%d>", value, start, end), nil
+		}
+
+		out, err := interpolate([]byte(input), escape)
+		if err != nil {
+			t.Fatalf(errFmt, nil, err)
+		}
+
+		expected := "a <upstream.example.com:2-25> ${MOCKSERVER_TEST_HOST}"
+		if string(out) != expected {
+			t.Errorf(errFmt, expected, string(out))
+		}
+	})
+
+	t.Run("return the line of a value that can't be escaped", func(t *testing.T) {
+		escape := func(b []byte, start, end int, value string) (string, error) {
+			return "", errors.New("can't be escaped")
+		}
+
+		_, err := interpolate([]byte("a\nb ${MOCKSERVER_TEST_HOST}"), escape)
+		if e, ok := err.(router.ErrInvalidConfig); !ok || e.Line != 2 {
+			t.Errorf(errFmt, router.ErrInvalidConfig{Line: 2}, err)
+		}
+	})
+}
+
+func TestEscapeStringShould(t *testing.T) {
+	for _, tc := range []struct {
+		input    string
+		expected string
+	}{
+		{input: `ab"c`, expected: `ab\"c`},
+		{input: `a\b`, expected: `a\\b`},
+		{input: "a\nb\t<c>", expected: `a\nb\t<c>`},
+	} {
+		t.Run(tc.input, func(t *testing.T) {
+			if out := EscapeString(tc.input); out != tc.expected {
+				t.Errorf(errFmt, tc.expected, out)
+			}
+		})
+	}
+}
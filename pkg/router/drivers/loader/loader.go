@@ -0,0 +1,257 @@
+// Package loader implements the format-agnostic loading of route
+// configuration documents shared by the file based drivers. This includes
+// environment variable interpolation, include directives, response path
+// resolution and loading every document within a directory or glob pattern.
+package loader
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/ncatelli/mockserver/pkg/router"
+)
+
+// ErrNoConfigFiles represents a directory or glob pattern that matched no
+// configuration files.
+type ErrNoConfigFiles struct {
+	Path string
+}
+
+func (e ErrNoConfigFiles) Error() string {
+	return fmt.Sprintf("no configuration files found at %s", e.Path)
+}
+
+// Entry represents a single item in a configuration document, which is either
+// a route or an include directive referencing another document.
+type Entry struct {
+	Include      string `yaml:"include,omitempty" json:"include,omitempty" toml:"include,omitempty"`
+	router.Route `yaml:",inline"`
+}
+
+// definesRoute returns true if any route field of the entry is set, ignoring
+// the line it was declared at.
+func (e Entry) definesRoute() bool {
+	route := e.Route
+	route.Line = 0
+
+	return !reflect.DeepEqual(route, router.Route{})
+}
+
+// Format describes how documents of a specific configuration format are
+// decoded and identified.
+type Format struct {
+	// Extensions lists the file extensions, including the leading dot, that
+	// are loaded when a Loader is pointed at a directory.
+	Extensions []string
+
+	// Decode unmarshals a document into its entries.
+	Decode func([]byte) ([]Entry, error)
+
+	// Escape, when set, escapes the value of an environment variable
+	// reference so that the value is read as written rather than altering
+	// the structure of the document. It is passed the document and the
+	// offsets of the reference within it, and returns an error if the value
+	// can't be represented at that position. Otherwise values are
+	// substituted verbatim.
+	Escape func(b []byte, start, end int, value string) (string, error)
+}
+
+// Loader loads routes from documents of a single Format. Each file based
+// driver embeds a Loader for its Format, adding only how its documents are
+// encoded.
+type Loader struct {
+	Format Format
+
+	// BaseDir, when set, is the directory that relative response paths and
+	// resource seeds are resolved against. Otherwise they are resolved
+	// against the directory of the file that declares them or, within an
+	// included file, the directory of the file that includes it.
+	BaseDir string
+}
+
+// Init takes a configuration map of strings to configure the Loader. The
+// current only accepted parameter is "base_dir", setting the BaseDir.
+func (l *Loader) Init(conf map[string]string) error {
+	if v, prs := conf["base_dir"]; prs == true {
+		l.BaseDir = v
+	}
+
+	return nil
+}
+
+// Extensions returns the file extensions loaded when pointed at a directory.
+func (l Loader) Extensions() []string {
+	return l.Format.Extensions
+}
+
+// Load takes an io.Reader and attempts to unmarshal the configuration into a
+// route slice. Any relative includes and response paths are resolved against
+// the working directory. On success, a slice of routes and nil is returned,
+// otherwise an error is returned.
+func (l Loader) Load(data io.Reader) ([]*router.Route, error) {
+	routes := make([]*router.Route, 0)
+	b, err := ioutil.ReadAll(data)
+	if err != nil {
+		return routes, err
+	}
+
+	return newSession(l).parse(b, origin{})
+}
+
+// LoadFromURL takes an io.Reader holding a document fetched from a URL and
+// attempts to unmarshal the configuration into a route slice. Any relative
+// includes are resolved against the URL, while relative response paths are
+// resolved against the working directory. On success, a slice of routes and
+// nil is returned, otherwise an error is returned.
+func (l Loader) LoadFromURL(u *url.URL, data io.Reader) ([]*router.Route, error) {
+	b, err := ioutil.ReadAll(data)
+	if err != nil {
+		return make([]*router.Route, 0), err
+	}
+
+	return newSession(l).parseURL(b, u)
+}
+
+// Decode takes an io.Reader and attempts to unmarshal the entries of a
+// document as written, without expanding environment variable references or
+// include directives, or resolving relative paths. This allows a document to
+// be converted to another format without altering it.
+func (l Loader) Decode(data io.Reader) ([]Entry, error) {
+	b, err := ioutil.ReadAll(data)
+	if err != nil {
+		return make([]Entry, 0), err
+	}
+
+	return l.Format.Decode(b)
+}
+
+// DecodeFromPath takes a path to a file, a directory or a glob pattern and
+// attempts to unmarshal the entries of every file it refers to as written,
+// in the lexical order of the paths of the files.
+func (l Loader) DecodeFromPath(path string) ([]Entry, error) {
+	entries := make([]Entry, 0)
+	files, err := l.ConfigFiles(path)
+	if err != nil {
+		return entries, err
+	}
+
+	errs := make([]error, 0)
+	for _, f := range files {
+		b, err := ioutil.ReadFile(f)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		e, err := l.Format.Decode(b)
+		if err != nil {
+			errs = append(errs, locate(err, f))
+			continue
+		}
+
+		entries = append(entries, e...)
+	}
+
+	return entries, joinErrors(errs)
+}
+
+// LoadFromFile takes a path an attempts to unmarshal a route slice from a
+// file. Any relative includes and response paths are resolved against the
+// directory of the file. On success, a slice of routes and nil is returned,
+// otherwise an error is returned.
+func (l Loader) LoadFromFile(path string) ([]*router.Route, error) {
+	routes, err := newSession(l).loadFile(path, nil)
+	if err != nil {
+		return make([]*router.Route, 0), err
+	}
+
+	return routes, nil
+}
+
+// LoadFromPath takes a path to a file, a directory or a glob pattern and
+// attempts to unmarshal a route slice from every file it refers to. When
+// pointed at a directory, all files with one of the Format's extensions
+// directly within the directory are loaded. Routes are merged in the lexical
+// order of the paths of the files they were loaded from. On success, the
+// merged slice of routes and nil is returned, otherwise an error describing
+// the problems found in every file is returned.
+func (l Loader) LoadFromPath(path string) ([]*router.Route, error) {
+	routes := make([]*router.Route, 0)
+	files, err := l.ConfigFiles(path)
+	if err != nil {
+		return routes, err
+	}
+
+	errs := make([]error, 0)
+	for _, f := range files {
+		r, err := l.LoadFromFile(f)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		routes = append(routes, r...)
+	}
+
+	return routes, joinErrors(errs)
+}
+
+// ConfigFiles returns the sorted list of configuration files that a path
+// refers to. The path may be a file, a directory or a glob pattern.
+func (l Loader) ConfigFiles(path string) ([]string, error) {
+	var files []string
+
+	if isPattern(path) {
+		matches, err := filepath.Glob(path)
+		if err != nil {
+			return nil, err
+		}
+
+		files = matches
+	} else if info, err := os.Stat(path); err != nil {
+		return nil, err
+	} else if !info.IsDir() {
+		return []string{path}, nil
+	} else {
+		entries, err := ioutil.ReadDir(path)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, e := range entries {
+			if !e.IsDir() && l.hasExtension(e.Name()) {
+				files = append(files, filepath.Join(path, e.Name()))
+			}
+		}
+	}
+
+	if len(files) == 0 {
+		return nil, ErrNoConfigFiles{Path: path}
+	}
+
+	sort.Strings(files)
+	return files, nil
+}
+
+func (l Loader) hasExtension(name string) bool {
+	ext := filepath.Ext(name)
+	for _, e := range l.Format.Extensions {
+		if ext == e {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isPattern returns true if the path contains any glob metacharacters.
+func isPattern(path string) bool {
+	return strings.ContainsAny(path, "*?[")
+}
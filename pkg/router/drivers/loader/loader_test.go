@@ -0,0 +1,114 @@
+package loader
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ncatelli/mockserver/pkg/router"
+)
+
+func TestConfigFilesShould(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mockserver")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for _, name := range []string{"b.json", "a.json", "c.yaml"} {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte("[]"), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	l := Loader{Format: Format{Extensions: []string{".json"}}}
+
+	t.Run("return sorted files matching the format's extensions within a directory", func(t *testing.T) {
+		expected := []string{filepath.Join(dir, "a.json"), filepath.Join(dir, "b.json")}
+		files, err := l.ConfigFiles(dir)
+		if err != nil {
+			t.Fatalf(errFmt, nil, err)
+		}
+
+		if !reflect.DeepEqual(expected, files) {
+			t.Errorf(errFmt, expected, files)
+		}
+	})
+
+	t.Run("return every file matching a pattern regardless of extension", func(t *testing.T) {
+		expected := []string{filepath.Join(dir, "c.yaml")}
+		files, err := l.ConfigFiles(filepath.Join(dir, "c.*"))
+		if err != nil {
+			t.Fatalf(errFmt, nil, err)
+		}
+
+		if !reflect.DeepEqual(expected, files) {
+			t.Errorf(errFmt, expected, files)
+		}
+	})
+
+	t.Run("return an ErrNoConfigFiles when nothing matches", func(t *testing.T) {
+		_, err := l.ConfigFiles(filepath.Join(dir, "*.toml"))
+		if _, ok := err.(ErrNoConfigFiles); !ok {
+			t.Errorf(errFmt, ErrNoConfigFiles{}, err)
+		}
+	})
+}
+
+func TestLoadFromPathShould(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mockserver")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	for _, name := range []string{"a.json", "b.json", "c.json"} {
+		content := "bad"
+		if name == "b.json" {
+			content = "good"
+		}
+
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	l := Loader{Format: Format{
+		Extensions: []string{".json"},
+		Decode: func(b []byte) ([]Entry, error) {
+			if string(b) == "bad" {
+				return nil, router.ErrValidation{{Line: 1, Reason: "bad document"}}
+			}
+
+			return []Entry{{Route: router.Route{Path: "/good"}}}, nil
+		},
+	}}
+
+	t.Run("report the problems found in every file", func(t *testing.T) {
+		_, err := l.LoadFromPath(dir)
+		problems, ok := err.(router.ErrValidation)
+		if !ok {
+			t.Fatalf(errFmt, router.ErrValidation{}, err)
+		}
+
+		sources := make([]string, 0, len(problems))
+		for _, p := range problems {
+			sources = append(sources, filepath.Base(p.Source))
+		}
+
+		expected := []string{"a.json", "c.json"}
+		if !reflect.DeepEqual(expected, sources) {
+			t.Errorf(errFmt, expected, sources)
+		}
+	})
+
+	t.Run("report the problems found in every decoded file", func(t *testing.T) {
+		_, err := l.DecodeFromPath(dir)
+		if err == nil || strings.Count(err.Error(), "bad document") != 2 {
+			t.Errorf(errFmt, "two problems", err)
+		}
+	})
+}
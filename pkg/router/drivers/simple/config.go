@@ -1,44 +1,126 @@
 package simple
 
 import (
+	"bytes"
 	"io"
-	"io/ioutil"
-	"path/filepath"
+	"regexp"
+	"strconv"
 
 	"github.com/ncatelli/mockserver/pkg/router"
-	"gopkg.in/yaml.v2"
+	"github.com/ncatelli/mockserver/pkg/router/drivers/loader"
+	"gopkg.in/yaml.v3"
 )
 
-// Load takes an io.Reader and attempts to unmarshal the configuration into a
-// route slice. On success, a slice of routes and nil is returned, otherwise an
-// error is returned.
-func Load(data io.Reader) ([]*router.Route, error) {
-	routes := make([]*router.Route, 0)
-	b, err := ioutil.ReadAll(data)
-	if err != nil {
-		return routes, err
+// Format describes the simple yaml configuration format. Unknown fields are
+// rejected.
+var Format = loader.Format{
+	Extensions: []string{".yaml", ".yml"},
+	Decode:     decode,
+	Escape:     escape,
+}
+
+// yamlErrorPattern matches the line number prefixed to yaml error messages.
+var yamlErrorPattern = regexp.MustCompile(`^(?:yaml: )?line (\d+): (.*)$`)
+
+func decode(b []byte) ([]loader.Entry, error) {
+	entries := make([]loader.Entry, 0)
+	dec := yaml.NewDecoder(bytes.NewReader(b))
+	dec.KnownFields(true)
+	if err := dec.Decode(&entries); err != nil && err != io.EOF {
+		return entries, decodeError(err)
 	}
 
-	if err = yaml.Unmarshal(b, &routes); err != nil {
-		return routes, err
+	// decoding into entries discards node positions, so the line each entry
+	// starts at is read from the document's node tree.
+	var doc yaml.Node
+	if err := yaml.Unmarshal(b, &doc); err == nil && len(doc.Content) > 0 {
+		for i, n := range doc.Content[0].Content {
+			if i < len(entries) {
+				entries[i].Line = n.Line
+				childLines(&entries[i].Route, n)
+			}
+		}
 	}
 
-	return routes, nil
+	return entries, nil
 }
 
-// LoadFromFile takes a path an attempts to unmarshal a route slice from a yaml
-// file. On success, a slice of routes and nil is returned, otherwise an error
-// is returned.
-func LoadFromFile(path string) ([]*router.Route, error) {
-	routes := make([]*router.Route, 0)
-	dat, err := ioutil.ReadFile(filepath.Clean(path))
-	if err != nil {
-		return routes, err
+// childLines records the line each child route of a group starts at from the
+// group's node.
+func childLines(route *router.Route, n *yaml.Node) {
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		if n.Content[i].Value != "routes" {
+			continue
+		}
+
+		for j, c := range n.Content[i+1].Content {
+			if j < len(route.Routes) {
+				route.Routes[j].Line = c.Line
+				childLines(route.Routes[j], c)
+			}
+		}
 	}
+}
+
+// decodeError converts a yaml error into an ErrValidation, splitting out
+// each problem and the line it was found at.
+func decodeError(err error) error {
+	messages := []string{err.Error()}
+	if te, ok := err.(*yaml.TypeError); ok {
+		messages = te.Errors
+	}
+
+	problems := make(router.ErrValidation, 0, len(messages))
+	for _, m := range messages {
+		problem := router.ErrInvalidConfig{Reason: m}
+		if match := yamlErrorPattern.FindStringSubmatch(m); match != nil {
+			problem.Line, _ = strconv.Atoi(match[1])
+			problem.Reason = match[2]
+		}
+
+		problems = append(problems, problem)
+	}
+
+	return problems
+}
+
+// Driver loads routes from simple yaml documents using the embedded
+// loader.Loader, adding only how the documents are encoded.
+type Driver struct {
+	loader.Loader
+}
+
+// New returns a Driver for simple yaml documents with no base directory.
+func New() *Driver {
+	return &Driver{Loader: loader.Loader{Format: Format}}
+}
+
+// Load takes an io.Reader and attempts to unmarshal the configuration into a
+// route slice using a Driver with no base directory.
+func Load(data io.Reader) ([]*router.Route, error) {
+	return New().Load(data)
+}
+
+// LoadFromFile takes a path and attempts to unmarshal a route slice from a
+// yaml file using a Driver with no base directory.
+func LoadFromFile(path string) ([]*router.Route, error) {
+	return New().LoadFromFile(path)
+}
+
+// LoadFromPath takes a path to a yaml file, a directory or a glob pattern and
+// attempts to unmarshal a route slice from every file it refers to using a
+// Driver with no base directory.
+func LoadFromPath(path string) ([]*router.Route, error) {
+	return New().LoadFromPath(path)
+}
 
-	if err = yaml.Unmarshal(dat, &routes); err != nil {
-		return routes, err
+// Encode writes entries to w as a simple yaml document.
+func (d Driver) Encode(w io.Writer, entries []loader.Entry) error {
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(entries); err != nil {
+		return err
 	}
 
-	return routes, nil
+	return enc.Close()
 }
@@ -3,11 +3,13 @@ package simple
 import (
 	"bytes"
 	"log"
+	"os"
 	"path/filepath"
 	"reflect"
 	"testing"
 
 	"github.com/ncatelli/mockserver/pkg/router"
+	"github.com/ncatelli/mockserver/pkg/router/drivers/loader"
 )
 
 const (
@@ -17,8 +19,7 @@ const (
 )
 
 var (
-	goodConfig = []byte(`
-- path: "/test/weighted/errors"
+	goodConfig = []byte(`- path: "/test/weighted/errors"
   method: GET
   handlers:
     - weight: 2
@@ -38,7 +39,8 @@ var (
 var expectedRoutes = []*router.Route{
 	&router.Route{
 		Path:   "/test/weighted/errors",
-		Method: "GET",
+		Method: router.Methods{"GET"},
+		Line:   1,
 		Handlers: []router.Handler{
 			router.Handler{
 				Weight: 2,
@@ -59,6 +61,19 @@ var expectedRoutes = []*router.Route{
 	},
 }
 
+// expectedRoutesFrom returns a copy of expectedRoutes with their source set
+// to the passed path.
+func expectedRoutesFrom(source string) []*router.Route {
+	routes := make([]*router.Route, 0, len(expectedRoutes))
+	for _, r := range expectedRoutes {
+		route := *r
+		route.Source = source
+		routes = append(routes, &route)
+	}
+
+	return routes
+}
+
 func TestLoadShould(t *testing.T) {
 	t.Run("load a valid configuration", func(t *testing.T) {
 		routes, err := Load(bytes.NewReader(goodConfig))
@@ -84,11 +99,12 @@ func TestLoadFromFileShould(t *testing.T) {
 			log.Fatal(err)
 		}
 
+		expected := expectedRoutesFrom(goodConfigPath)
 		routes, err := LoadFromFile(gp)
 		if err != nil {
-			t.Errorf(errFmt, expectedRoutes, err)
-		} else if !reflect.DeepEqual(routes, expectedRoutes) {
-			t.Errorf(errFmt, expectedRoutes, routes)
+			t.Errorf(errFmt, expected, err)
+		} else if !reflect.DeepEqual(routes, expected) {
+			t.Errorf(errFmt, expected, routes)
 		}
 	})
 
@@ -103,3 +119,138 @@ func TestLoadFromFileShould(t *testing.T) {
 		}
 	})
 }
+
+func TestLoadFromPathShould(t *testing.T) {
+	expectedPaths := []string{"/first", "/second"}
+
+	for _, tc := range []struct {
+		name string
+		path string
+	}{
+		{name: "a directory", path: "test_fixtures/dir"},
+		{name: "a glob pattern", path: "test_fixtures/dir/*.y*ml"},
+	} {
+		t.Run("merge routes in file order from "+tc.name, func(t *testing.T) {
+			routes, err := LoadFromPath(tc.path)
+			if err != nil {
+				t.Fatalf(errFmt, nil, err)
+			}
+
+			paths := make([]string, 0, len(routes))
+			for _, r := range routes {
+				paths = append(paths, r.Path)
+			}
+
+			if !reflect.DeepEqual(expectedPaths, paths) {
+				t.Errorf(errFmt, expectedPaths, paths)
+			}
+		})
+	}
+
+	t.Run("load a single file", func(t *testing.T) {
+		expected := expectedRoutesFrom(goodConfigPath)
+		routes, err := LoadFromPath(goodConfigPath)
+		if err != nil {
+			t.Errorf(errFmt, expected, err)
+		} else if !reflect.DeepEqual(routes, expected) {
+			t.Errorf(errFmt, expected, routes)
+		}
+	})
+
+	t.Run("return an ErrNoConfigFiles when a pattern matches nothing", func(t *testing.T) {
+		_, err := LoadFromPath("test_fixtures/dir/*.json")
+		if _, ok := err.(loader.ErrNoConfigFiles); !ok {
+			t.Errorf(errFmt, loader.ErrNoConfigFiles{}, err)
+		}
+	})
+}
+
+func TestEnvironmentInterpolationShould(t *testing.T) {
+	os.Setenv("MOCKSERVER_TEST_HOST", "upstream.example.com")
+	defer os.Unsetenv("MOCKSERVER_TEST_HOST")
+
+	t.Run("expand references in route definitions", func(t *testing.T) {
+		config := []byte(`
+- path: "/proxy"
+  method: GET
+  handlers:
+  - weight: 1
+    response_headers:
+      location: 'http://${MOCKSERVER_TEST_HOST}/'
+    static_response: ''
+    response_status: ${MOCKSERVER_TEST_STATUS:-302}
+`)
+
+		routes, err := Load(bytes.NewReader(config))
+		if err != nil {
+			t.Fatalf(errFmt, nil, err)
+		}
+
+		h := routes[0].Handlers[0]
+		if l := h.ResponseHeaders["location"]; l != "http://upstream.example.com/" {
+			t.Errorf(errFmt, "http://upstream.example.com/", l)
+		}
+
+		if h.ResponseStatus != 302 {
+			t.Errorf(errFmt, 302, h.ResponseStatus)
+		}
+	})
+}
+
+func TestStrictDecodingShould(t *testing.T) {
+	t.Run("record the line each route starts at", func(t *testing.T) {
+		routes, err := Load(bytes.NewReader(append(goodConfig, goodConfig...)))
+		if err != nil {
+			t.Fatalf(errFmt, nil, err)
+		}
+
+		lines := []int{routes[0].Line, routes[1].Line}
+		if expected := []int{1, 14}; !reflect.DeepEqual(expected, lines) {
+			t.Errorf(errFmt, expected, lines)
+		}
+	})
+
+	t.Run("record the line each route within a group starts at", func(t *testing.T) {
+		config := []byte(`- path_prefix: /v1
+  routes:
+    - path: /users
+      method: GET
+      handlers:
+        - weight: 1
+          response_status: 200
+    - path: /orders
+      method: GET
+      handlers:
+        - weight: 1
+          response_status: 200
+`)
+
+		routes, err := Load(bytes.NewReader(config))
+		if err != nil {
+			t.Fatalf(errFmt, nil, err)
+		}
+
+		lines := []int{routes[0].Line, routes[0].Routes[0].Line, routes[0].Routes[1].Line}
+		if expected := []int{1, 3, 8}; !reflect.DeepEqual(expected, lines) {
+			t.Errorf(errFmt, expected, lines)
+		}
+	})
+
+	t.Run("report unknown fields with the file and line they occur at", func(t *testing.T) {
+		_, err := LoadFromFile("test_fixtures/strict/typo.yaml")
+
+		problems, ok := err.(router.ErrValidation)
+		if !ok || len(problems) != 1 {
+			t.Fatalf(errFmt, router.ErrValidation{}, err)
+		}
+
+		expected := router.ErrInvalidConfig{
+			Source: "test_fixtures/strict/typo.yaml",
+			Line:   13,
+			Reason: "field respone_status not found in type router.Handler",
+		}
+		if problems[0] != expected {
+			t.Errorf(errFmt, expected, problems[0])
+		}
+	})
+}
@@ -0,0 +1,258 @@
+package simple
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/ncatelli/mockserver/pkg/router/drivers/loader"
+)
+
+// the kinds of scalar a position within a yaml document may fall within.
+const (
+	plainScalar = iota
+	singleQuotedScalar
+	doubleQuotedScalar
+	blockScalar
+	comment
+)
+
+// position describes where a reference falls within a yaml document.
+type position struct {
+	kind int
+
+	// scalarStart is true if the position is at the start of a plain scalar,
+	// rather than within one.
+	scalarStart bool
+
+	// flow is true if the position is within a flow sequence or mapping.
+	flow bool
+
+	// indent is the indentation of the line the position falls on.
+	indent string
+}
+
+// escape escapes the value of a reference so that it is read as written. A
+// value within a quoted scalar is escaped for that style of quoting, and one
+// within a block scalar is indented to match. A reference making up an entire
+// plain scalar is substituted verbatim if it is safe to do so, preserving
+// the resolution of numbers and booleans, and is otherwise substituted as a
+// double-quoted scalar. A value that would alter a plain scalar it is part
+// of is an error, as only the whole scalar could be quoted.
+func escape(b []byte, start, end int, value string) (string, error) {
+	p := positionAt(b, start)
+	switch p.kind {
+	case singleQuotedScalar:
+		if strings.ContainsAny(value, "\r\n") {
+			return "", errors.New("contains a line break and must be substituted within a double-quoted string")
+		}
+
+		return strings.ReplaceAll(value, "'", "''"), nil
+	case doubleQuotedScalar:
+		return loader.EscapeString(value), nil
+	case blockScalar:
+		return strings.ReplaceAll(value, "\n", "\n"+p.indent), nil
+	case plainScalar:
+		whole := p.scalarStart && scalarEnds(b[end:], p.flow)
+		if plainSafe(value, surrounding(b, start, end, value), p.flow, whole) {
+			return value, nil
+		} else if whole {
+			return `"` + loader.EscapeString(value) + `"`, nil
+		}
+
+		return "", errors.New("can't be written within an unquoted scalar, quote the scalar")
+	}
+
+	return value, nil
+}
+
+// positionAt scans a yaml document up to offset, returning the position that
+// offset falls at.
+func positionAt(b []byte, offset int) position {
+	p := position{kind: plainScalar, scalarStart: true}
+	flow := 0
+	lineStart := 0
+	blockIndent := -1
+	blockPending := false
+
+	for i := 0; i < offset; i++ {
+		c := b[i]
+		if c == '\n' {
+			if p.kind == comment || p.kind == plainScalar {
+				p.kind = plainScalar
+				p.scalarStart = true
+			}
+
+			if blockPending {
+				blockIndent = indentation(b[lineStart:])
+				blockPending = false
+			}
+
+			lineStart = i + 1
+			if blockIndent >= 0 {
+				p.kind = blockScalar
+			}
+
+			continue
+		}
+
+		// a block scalar ends at the first non-blank line indented no
+		// further than the line that introduced it.
+		if p.kind == blockScalar && i == lineStart {
+			if indent := indentation(b[i:]); indent <= blockIndent && !blankLine(b[i:]) {
+				p.kind = plainScalar
+				p.scalarStart = true
+				blockIndent = -1
+			}
+		}
+
+		switch p.kind {
+		case plainScalar:
+			next := byte('\n')
+			if i+1 < len(b) {
+				next = b[i+1]
+			}
+
+			switch {
+			case c == ' ' || c == '\t':
+			case c == '#' && (i == lineStart || b[i-1] == ' ' || b[i-1] == '\t'):
+				p.kind = comment
+			case p.scalarStart && c == '\'':
+				p.kind = singleQuotedScalar
+			case p.scalarStart && c == '"':
+				p.kind = doubleQuotedScalar
+			case p.scalarStart && (c == '[' || c == '{'):
+				flow++
+			case flow > 0 && (c == ']' || c == '}'):
+				flow--
+				p.scalarStart = false
+			case flow > 0 && c == ',':
+				p.scalarStart = true
+			case c == ':' && isSeparator(next, flow > 0):
+				// a mapping value follows the key.
+				p.scalarStart = true
+			case p.scalarStart && (c == '-' || c == '?') && isSeparator(next, flow > 0):
+				// sequence entries and complex keys are followed by a new
+				// scalar.
+			case p.scalarStart && flow == 0 && (c == '|' || c == '>'):
+				blockPending = true
+				p.scalarStart = false
+			default:
+				p.scalarStart = false
+			}
+		case singleQuotedScalar:
+			if c == '\'' {
+				if i+1 < offset && b[i+1] == '\'' {
+					i++
+				} else {
+					p.kind = plainScalar
+					p.scalarStart = false
+				}
+			}
+		case doubleQuotedScalar:
+			if c == '\\' {
+				i++
+			} else if c == '"' {
+				p.kind = plainScalar
+				p.scalarStart = false
+			}
+		}
+	}
+
+	p.flow = flow > 0
+	p.indent = strings.Repeat(" ", indentation(b[lineStart:]))
+	return p
+}
+
+// indentation returns the number of spaces a line begins with.
+func indentation(line []byte) int {
+	n := 0
+	for n < len(line) && line[n] == ' ' {
+		n++
+	}
+
+	return n
+}
+
+// blankLine returns true if a line holds nothing but whitespace.
+func blankLine(line []byte) bool {
+	for _, c := range line {
+		switch c {
+		case '\n':
+			return true
+		case ' ', '\t', '\r':
+		default:
+			return false
+		}
+	}
+
+	return true
+}
+
+// isSeparator returns true if c separates an indicator from the value
+// following it.
+func isSeparator(c byte, flow bool) bool {
+	return c == ' ' || c == '\t' || c == '\r' || c == '\n' || (flow && (c == ',' || c == ']' || c == '}'))
+}
+
+// scalarEnds returns true if rest begins with the end of a plain scalar,
+// allowing for trailing whitespace.
+func scalarEnds(rest []byte, flow bool) bool {
+	trimmed := strings.TrimLeft(string(rest), " \t")
+	if len(trimmed) == 0 {
+		return true
+	}
+
+	switch trimmed[0] {
+	case '\r', '\n':
+		return true
+	case '#':
+		return len(trimmed) < len(rest)
+	case ':':
+		return len(trimmed) == 1 || isSeparator(trimmed[1], flow)
+	case ',', ']', '}':
+		return flow
+	}
+
+	return false
+}
+
+// surrounding returns a value along with the characters either side of the
+// reference it is substituted for.
+func surrounding(b []byte, start, end int, value string) string {
+	before, after := "", ""
+	if start > 0 {
+		before = string(b[start-1])
+	}
+
+	if end < len(b) {
+		after = string(b[end])
+	}
+
+	return before + value + after
+}
+
+// plainSafe returns true if a value can be substituted into a plain scalar
+// without altering the structure of the document. surrounded is the value
+// along with the characters either side of it, and whole is true if the
+// value makes up the entire scalar.
+func plainSafe(value, surrounded string, flow, whole bool) bool {
+	if len(value) == 0 {
+		return true
+	} else if strings.ContainsAny(value, "\r\n") || strings.Contains(surrounded, ": ") || strings.Contains(surrounded, " #") {
+		return false
+	} else if flow && strings.ContainsAny(value, ",[]{}") {
+		return false
+	} else if !whole {
+		return true
+	}
+
+	if strings.TrimSpace(value) != value || strings.HasSuffix(value, ":") {
+		return false
+	} else if strings.ContainsAny(value[:1], ",[]{}#&*!|>'\"%@`") {
+		return false
+	} else if strings.ContainsAny(value[:1], "-?:") && (len(value) == 1 || value[1] == ' ') {
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,80 @@
+package simple
+
+import (
+	"bytes"
+	"os"
+	"testing"
+)
+
+func TestEscapeShould(t *testing.T) {
+	os.Setenv("MOCKSERVER_TEST_VALUE", `it's "a: b" #c \d`)
+	os.Setenv("MOCKSERVER_TEST_LINES", "a\nb")
+	defer os.Unsetenv("MOCKSERVER_TEST_VALUE")
+	defer os.Unsetenv("MOCKSERVER_TEST_LINES")
+
+	for _, tc := range []struct {
+		name     string
+		response string
+		expected string
+	}{
+		{name: "quote a value making up a plain scalar", response: `${MOCKSERVER_TEST_VALUE}`, expected: `it's "a: b" #c \d`},
+		{name: "escape a value within a single-quoted scalar", response: `'> ${MOCKSERVER_TEST_VALUE}'`, expected: `> it's "a: b" #c \d`},
+		{name: "escape a value within a double-quoted scalar", response: `"> ${MOCKSERVER_TEST_VALUE}"`, expected: `> it's "a: b" #c \d`},
+		{name: "indent a value within a block scalar", response: "|\n      > ${MOCKSERVER_TEST_LINES}", expected: "> a\nb\n"},
+		{name: "ignore quotes within comments", response: "'${MOCKSERVER_TEST_VALUE}' # it's", expected: `it's "a: b" #c \d`},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			config := []byte(`# it's "quoted"
+- path: "/value"
+  method: [GET]
+  handlers:
+  - weight: 1
+    static_response: ` + tc.response + `
+    response_status: 200
+`)
+
+			routes, err := Load(bytes.NewReader(config))
+			if err != nil {
+				t.Fatalf(errFmt, nil, err)
+			}
+
+			if r := routes[0].Handlers[0].StaticResponse; r != tc.expected {
+				t.Errorf(errFmt, tc.expected, r)
+			}
+		})
+	}
+
+	t.Run("substitute values within flow collections", func(t *testing.T) {
+		config := []byte(`- path: "/value"
+  method: [GET, "${MOCKSERVER_TEST_METHOD:-POST}", ${MOCKSERVER_TEST_METHOD:-PUT}]
+  handlers: [{weight: ${MOCKSERVER_TEST_WEIGHT:-1}, static_response: ${MOCKSERVER_TEST_VALUE}, response_status: 200}]
+`)
+
+		routes, err := Load(bytes.NewReader(config))
+		if err != nil {
+			t.Fatalf(errFmt, nil, err)
+		}
+
+		if m := routes[0].Method.String(); m != "GET,POST,PUT" {
+			t.Errorf(errFmt, "GET,POST,PUT", m)
+		}
+
+		if r := routes[0].Handlers[0].StaticResponse; r != `it's "a: b" #c \d` {
+			t.Errorf(errFmt, `it's "a: b" #c \d`, r)
+		}
+	})
+
+	t.Run("return an error for a value that would alter the plain scalar it is part of", func(t *testing.T) {
+		config := []byte(`- path: "/value"
+  method: GET
+  handlers:
+  - weight: 1
+    static_response: value ${MOCKSERVER_TEST_VALUE}
+    response_status: 200
+`)
+
+		if _, err := Load(bytes.NewReader(config)); err == nil {
+			t.Errorf(errFmt, "an error", err)
+		}
+	})
+}
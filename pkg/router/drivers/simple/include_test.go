@@ -0,0 +1,153 @@
+package simple
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/ncatelli/mockserver/pkg/router/drivers/loader"
+)
+
+func TestIncludesShould(t *testing.T) {
+	t.Run("expand included routes in place", func(t *testing.T) {
+		routes, err := LoadFromFile("test_fixtures/include/main.yaml")
+		if err != nil {
+			t.Fatalf(errFmt, nil, err)
+		}
+
+		expected := []string{"/health", "/orders"}
+		paths := make([]string, 0, len(routes))
+		for _, r := range routes {
+			paths = append(paths, r.Path)
+		}
+
+		if !reflect.DeepEqual(expected, paths) {
+			t.Errorf(errFmt, expected, paths)
+		}
+	})
+
+	t.Run("resolve response paths within an included file relative to the including file", func(t *testing.T) {
+		routes, err := LoadFromFile("test_fixtures/include/main.yaml")
+		if err != nil {
+			t.Fatalf(errFmt, nil, err)
+		}
+
+		expected := filepath.Join("test_fixtures", "include", "shared", "health.json")
+		if rp := routes[0].Handlers[0].ResponsePath; rp != expected {
+			t.Errorf(errFmt, expected, rp)
+		}
+	})
+
+	t.Run("record the file each route was loaded from", func(t *testing.T) {
+		routes, err := LoadFromFile("test_fixtures/include/main.yaml")
+		if err != nil {
+			t.Fatalf(errFmt, nil, err)
+		}
+
+		expected := []string{
+			filepath.Join("test_fixtures", "include", "shared", "health.yaml"),
+			filepath.Join("test_fixtures", "include", "main.yaml"),
+		}
+		sources := make([]string, 0, len(routes))
+		for _, r := range routes {
+			sources = append(sources, r.Source)
+		}
+
+		if !reflect.DeepEqual(expected, sources) {
+			t.Errorf(errFmt, expected, sources)
+		}
+	})
+
+	t.Run("include documents from a URL relative to the including URL", func(t *testing.T) {
+		mux := http.NewServeMux()
+		mux.HandleFunc("/mocks/main.yaml", func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("- include: shared.yaml\n"))
+		})
+		mux.HandleFunc("/mocks/shared.yaml", func(w http.ResponseWriter, r *http.Request) {
+			w.Write(goodConfig)
+		})
+		testServer := httptest.NewServer(mux)
+		defer func() { testServer.Close() }()
+
+		config := []byte("- include: " + testServer.URL + "/mocks/main.yaml\n")
+		routes, err := Load(bytes.NewReader(config))
+		if err != nil {
+			t.Fatalf(errFmt, nil, err)
+		}
+
+		expected := expectedRoutesFrom(testServer.URL + "/mocks/shared.yaml")
+		if !reflect.DeepEqual(expected, routes) {
+			t.Errorf(errFmt, expected, routes)
+		}
+	})
+
+	t.Run("resolve includes within a document loaded from a URL relative to the URL", func(t *testing.T) {
+		mux := http.NewServeMux()
+		mux.HandleFunc("/mocks/shared/health.yaml", func(w http.ResponseWriter, r *http.Request) {
+			w.Write(goodConfig)
+		})
+		testServer := httptest.NewServer(mux)
+		defer func() { testServer.Close() }()
+
+		u, err := url.Parse(testServer.URL + "/mocks/main.yaml")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		routes, err := New().LoadFromURL(u, bytes.NewReader([]byte("- include: shared/health.yaml\n")))
+		if err != nil {
+			t.Fatalf(errFmt, nil, err)
+		}
+
+		expected := expectedRoutesFrom(testServer.URL + "/mocks/shared/health.yaml")
+		if !reflect.DeepEqual(expected, routes) {
+			t.Errorf(errFmt, expected, routes)
+		}
+	})
+
+	t.Run("return an error when an included URL responds with a non-2xx status", func(t *testing.T) {
+		testServer := httptest.NewServer(http.NotFoundHandler())
+		defer func() { testServer.Close() }()
+
+		config := []byte("- include: " + testServer.URL + "/missing.yaml\n")
+		if _, err := Load(bytes.NewReader(config)); err == nil {
+			t.Errorf(errFmt, "an error", err)
+		}
+	})
+
+	t.Run("return an error on an include cycle", func(t *testing.T) {
+		_, err := LoadFromFile("test_fixtures/include/cycle_a.yaml")
+
+		var cycleErr loader.ErrIncludeCycle
+		if !errors.As(err, &cycleErr) {
+			t.Errorf(errFmt, loader.ErrIncludeCycle{}, err)
+		}
+	})
+
+	t.Run("return an error when an include defines route fields", func(t *testing.T) {
+		_, err := LoadFromFile("test_fixtures/include/invalid.yaml")
+		if _, ok := err.(loader.ErrInvalidInclude); !ok {
+			t.Errorf(errFmt, loader.ErrInvalidInclude{}, err)
+		}
+	})
+
+	for _, field := range []string{
+		"priority: 1",
+		"fallback: true",
+		"resource: {name: users}",
+		"request_headers: {accept: json}",
+		"middleware: {logging: {}}",
+	} {
+		t.Run("return an error when an include defines "+field, func(t *testing.T) {
+			config := []byte("- include: shared.yaml\n  " + field + "\n")
+			if _, err := Load(bytes.NewReader(config)); !errors.As(err, &loader.ErrInvalidInclude{}) {
+				t.Errorf(errFmt, loader.ErrInvalidInclude{}, err)
+			}
+		})
+	}
+}
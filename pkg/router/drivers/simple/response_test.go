@@ -0,0 +1,100 @@
+package simple
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+
+	"github.com/ncatelli/mockserver/pkg/router"
+)
+
+func TestResponsePathResolutionShould(t *testing.T) {
+	t.Run("resolve relative response paths against the config file directory", func(t *testing.T) {
+		routes, err := LoadFromFile("test_fixtures/response/relative.yaml")
+		if err != nil {
+			t.Fatalf(errFmt, nil, err)
+		}
+
+		expected := filepath.Join("test_fixtures", "response", "bodies", "body.txt")
+		if rp := routes[0].Handlers[0].ResponsePath; rp != expected {
+			t.Errorf(errFmt, expected, rp)
+		}
+	})
+
+	t.Run("resolve relative response paths against the base directory when set", func(t *testing.T) {
+		d := New()
+		d.BaseDir = "test_fixtures/response"
+		config := []byte(`
+- path: "/relative"
+  method: GET
+  handlers:
+  - weight: 1
+    response_path: bodies/body.txt
+    response_status: 200
+`)
+
+		routes, err := d.Load(bytes.NewReader(config))
+		if err != nil {
+			t.Fatalf(errFmt, nil, err)
+		}
+
+		expected := filepath.Join("test_fixtures", "response", "bodies", "body.txt")
+		if rp := routes[0].Handlers[0].ResponsePath; rp != expected {
+			t.Errorf(errFmt, expected, rp)
+		}
+	})
+
+	t.Run("resolve relative resource seeds against the base directory when set", func(t *testing.T) {
+		d := New()
+		d.BaseDir = "test_fixtures/response"
+		config := []byte(`
+- path: "/users"
+  resource:
+    name: users
+    seed: bodies/users.json
+`)
+
+		routes, err := d.Load(bytes.NewReader(config))
+		if err != nil {
+			t.Fatalf(errFmt, nil, err)
+		}
+
+		expected := filepath.Join("test_fixtures", "response", "bodies", "users.json")
+		if seed := routes[0].Resource.Seed; seed != expected {
+			t.Errorf(errFmt, expected, seed)
+		}
+	})
+
+	t.Run("leave a missing response file to validation", func(t *testing.T) {
+		routes, err := LoadFromFile("test_fixtures/response/missing.yaml")
+		if err != nil {
+			t.Fatalf(errFmt, nil, err)
+		}
+
+		if err := router.Validate(routes); err == nil {
+			t.Errorf(errFmt, "a validation error", err)
+		}
+	})
+
+	t.Run("leave a response path referring to a directory to validation", func(t *testing.T) {
+		d := New()
+		d.BaseDir = "test_fixtures/response"
+		config := []byte(`
+- path: "/directory"
+  method: GET
+  handlers:
+  - weight: 1
+    response_path: bodies
+    response_status: 200
+`)
+
+		routes, err := d.Load(bytes.NewReader(config))
+		if err != nil {
+			t.Fatalf(errFmt, nil, err)
+		}
+
+		if err := router.Validate(routes); err == nil {
+			t.Errorf(errFmt, "a validation error", err)
+		}
+	})
+}
@@ -0,0 +1,254 @@
+package toml
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"regexp"
+	"strings"
+
+	btoml "github.com/BurntSushi/toml"
+	"github.com/ncatelli/mockserver/pkg/router"
+	"github.com/ncatelli/mockserver/pkg/router/drivers/loader"
+)
+
+// document represents the top-level table of a toml configuration, as toml
+// documents can't be a bare array.
+type document struct {
+	Routes []loader.Entry `toml:"routes"`
+}
+
+// Format describes the toml configuration format. A toml document declares
+// its routes and include directives as an array of tables under the routes
+// key. Unknown keys are rejected.
+var Format = loader.Format{
+	Extensions: []string{".toml"},
+	Decode:     decode,
+	Escape:     escape,
+}
+
+// the kinds of string a position within a toml document may fall within.
+const (
+	noString = iota
+	comment
+	basicString
+	literalString
+	multilineBasicString
+	multilineLiteralString
+)
+
+// escape escapes the value of a reference within a basic string so that it
+// can't end the string. Literal strings can't contain escapes, so values
+// that would end one are an error. Values substituted outside of a string,
+// such as numbers, are left unmodified.
+func escape(b []byte, start, end int, value string) (string, error) {
+	switch stringAt(b[:start]) {
+	case basicString, multilineBasicString:
+		return loader.EscapeString(value), nil
+	case literalString:
+		if strings.ContainsAny(value, "'\r\n") {
+			return "", errors.New("can't be written within a literal string, use a basic string")
+		}
+	case multilineLiteralString:
+		if strings.Contains(value, "'''") {
+			return "", errors.New("can't be written within a multi-line literal string, use a basic string")
+		}
+	}
+
+	return value, nil
+}
+
+// stringAt returns the kind of string that the end of b falls within.
+func stringAt(b []byte) int {
+	kind := noString
+	for i := 0; i < len(b); i++ {
+		rest := b[i:]
+		switch kind {
+		case noString:
+			switch {
+			case b[i] == '#':
+				kind = comment
+			case bytes.HasPrefix(rest, []byte(`"""`)):
+				kind = multilineBasicString
+				i += 2
+			case bytes.HasPrefix(rest, []byte("'''")):
+				kind = multilineLiteralString
+				i += 2
+			case b[i] == '"':
+				kind = basicString
+			case b[i] == '\'':
+				kind = literalString
+			}
+		case comment:
+			if b[i] == '\n' {
+				kind = noString
+			}
+		case basicString:
+			if b[i] == '\\' {
+				i++
+			} else if b[i] == '"' {
+				kind = noString
+			}
+		case literalString:
+			if b[i] == '\'' {
+				kind = noString
+			}
+		case multilineBasicString:
+			if b[i] == '\\' {
+				i++
+			} else if bytes.HasPrefix(rest, []byte(`"""`)) {
+				kind = noString
+				i += 2
+			}
+		case multilineLiteralString:
+			if bytes.HasPrefix(rest, []byte("'''")) {
+				kind = noString
+				i += 2
+			}
+		}
+	}
+
+	return kind
+}
+
+// routeHeaderPattern matches the header of each table in the routes array.
+var routeHeaderPattern = regexp.MustCompile(`^\s*\[\[\s*routes\s*\]\]`)
+
+func decode(b []byte) ([]loader.Entry, error) {
+	doc := document{Routes: make([]loader.Entry, 0)}
+	md, err := btoml.Decode(string(b), &doc)
+	if err != nil {
+		problem := router.ErrInvalidConfig{Reason: err.Error()}
+		if pe, ok := err.(btoml.ParseError); ok {
+			problem.Line = pe.Position.Line
+			problem.Reason = pe.Message
+		}
+
+		return doc.Routes, router.ErrValidation{problem}
+	}
+
+	lines := strings.Split(string(b), "\n")
+	if undecoded := md.Undecoded(); len(undecoded) > 0 {
+		problems := make(router.ErrValidation, 0, len(undecoded))
+		for _, key := range undecoded {
+			if selfDecoded(reflect.TypeOf(doc), key) {
+				continue
+			}
+
+			problems = append(problems, router.ErrInvalidConfig{
+				Line:   keyLine(lines, key),
+				Reason: fmt.Sprintf("unknown field %q", key.String()),
+			})
+		}
+
+		if len(problems) > 0 {
+			return doc.Routes, problems
+		}
+	}
+
+	i := 0
+	for n, l := range lines {
+		if i < len(doc.Routes) && routeHeaderPattern.MatchString(l) {
+			doc.Routes[i].Line = n + 1
+			i++
+		}
+	}
+
+	return doc.Routes, nil
+}
+
+// unmarshalerType is the type of the interface implemented by values that
+// decode themselves.
+var unmarshalerType = reflect.TypeOf((*btoml.Unmarshaler)(nil)).Elem()
+
+// selfDecoded returns true if the key falls beneath a value that implements
+// toml.Unmarshaler. The decoder doesn't track which keys such values consume,
+// so they're responsible for rejecting unknown keys themselves.
+func selfDecoded(t reflect.Type, key btoml.Key) bool {
+	for _, k := range key {
+		for t.Kind() == reflect.Ptr || t.Kind() == reflect.Slice {
+			t = t.Elem()
+		}
+
+		switch t.Kind() {
+		case reflect.Interface:
+			// arbitrary values are decoded wholesale.
+			return true
+		case reflect.Map:
+			t = t.Elem()
+		case reflect.Struct:
+			f, ok := tomlField(t, k)
+			if !ok {
+				return false
+			}
+
+			t = f.Type
+		default:
+			return false
+		}
+
+		if t.Implements(unmarshalerType) || reflect.PtrTo(t).Implements(unmarshalerType) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// tomlField returns the field of a struct, including fields of embedded
+// structs, that the passed toml key decodes to.
+func tomlField(t reflect.Type, key string) (reflect.StructField, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.Anonymous && f.Type.Kind() == reflect.Struct {
+			if ef, ok := tomlField(f.Type, key); ok {
+				return ef, true
+			}
+
+			continue
+		}
+
+		name := strings.Split(f.Tag.Get("toml"), ",")[0]
+		if name == key || (len(name) == 0 && strings.EqualFold(f.Name, key)) {
+			return f, true
+		}
+	}
+
+	return reflect.StructField{}, false
+}
+
+// keyLine returns the first line assigning the last component of the passed
+// key, or 0 if it can't be found.
+func keyLine(lines []string, key btoml.Key) int {
+	if len(key) == 0 {
+		return 0
+	}
+
+	pattern := regexp.MustCompile(`^\s*"?` + regexp.QuoteMeta(key[len(key)-1]) + `"?\s*=`)
+	for n, l := range lines {
+		if pattern.MatchString(l) {
+			return n + 1
+		}
+	}
+
+	return 0
+}
+
+// Driver loads routes from toml documents using the embedded loader.Loader,
+// adding only how the documents are encoded.
+type Driver struct {
+	loader.Loader
+}
+
+// New returns a Driver for toml documents with no base directory.
+func New() *Driver {
+	return &Driver{Loader: loader.Loader{Format: Format}}
+}
+
+// Encode writes entries to w as a toml document, declaring each entry as a
+// table in the routes array.
+func (d Driver) Encode(w io.Writer, entries []loader.Entry) error {
+	return btoml.NewEncoder(w).Encode(document{Routes: entries})
+}
@@ -0,0 +1,263 @@
+package toml
+
+import (
+	"bytes"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/ncatelli/mockserver/pkg/router"
+)
+
+const (
+	goodConfigPath string = "test_fixtures/good.toml"
+	errFmt         string = "want %v, got %v"
+)
+
+var (
+	badConfig     = []byte(`[[routes]`)
+	includeConfig = []byte(`[[routes]]
+include = "test_fixtures/good.toml"`)
+)
+
+var expectedRoutes = []*router.Route{
+	&router.Route{
+		Path:   "/test/weighted/errors",
+		Method: router.Methods{"GET"},
+		Handlers: []router.Handler{
+			router.Handler{
+				Weight: 2,
+				ResponseHeaders: map[string]string{
+					"content-type": "application/json",
+				},
+				StaticResponse: "{\"resp\": \"Ok\"}",
+				ResponseStatus: 200,
+			},
+			router.Handler{
+				Weight: 1,
+				ResponseHeaders: map[string]string{
+					"content-type": "text/plain",
+				},
+				ResponseStatus: 500,
+			},
+		},
+		Source: goodConfigPath,
+		Line:   1,
+	},
+}
+
+func TestLoadFromFileShould(t *testing.T) {
+	t.Run("load a valid configuration", func(t *testing.T) {
+		routes, err := New().LoadFromFile(goodConfigPath)
+		if err != nil {
+			t.Errorf(errFmt, expectedRoutes, err)
+		} else if !reflect.DeepEqual(routes, expectedRoutes) {
+			t.Errorf(errFmt, expectedRoutes, routes)
+		}
+	})
+
+	t.Run("load a directory of configurations", func(t *testing.T) {
+		routes, err := New().LoadFromPath("test_fixtures")
+		if err != nil {
+			t.Errorf(errFmt, expectedRoutes, err)
+		} else if !reflect.DeepEqual(routes, expectedRoutes) {
+			t.Errorf(errFmt, expectedRoutes, routes)
+		}
+	})
+}
+
+func TestLoadShould(t *testing.T) {
+	t.Run("expand include directives", func(t *testing.T) {
+		routes, err := New().Load(bytes.NewReader(includeConfig))
+		if err != nil {
+			t.Errorf(errFmt, expectedRoutes, err)
+		} else if !reflect.DeepEqual(routes, expectedRoutes) {
+			t.Errorf(errFmt, expectedRoutes, routes)
+		}
+	})
+
+	t.Run("return an error on a non-valid configuration", func(t *testing.T) {
+		_, err := New().Load(bytes.NewReader(badConfig))
+		if err == nil {
+			t.Errorf(errFmt, "an error", err)
+		}
+	})
+}
+
+func TestStrictDecodingShould(t *testing.T) {
+	t.Run("report unknown fields with the line they occur at", func(t *testing.T) {
+		config := []byte(`[[routes]]
+path = "/typo"
+method = "GET"
+
+  [[routes.handlers]]
+  weight = 1
+  respone_status = 200
+`)
+
+		_, err := New().Load(bytes.NewReader(config))
+
+		problems, ok := err.(router.ErrValidation)
+		if !ok || len(problems) != 1 {
+			t.Fatalf(errFmt, router.ErrValidation{}, err)
+		}
+
+		expected := router.ErrInvalidConfig{
+			Line:   7,
+			Reason: `unknown field "routes.handlers.respone_status"`,
+		}
+		if problems[0] != expected {
+			t.Errorf(errFmt, expected, problems[0])
+		}
+	})
+}
+
+func TestGroupsShould(t *testing.T) {
+	t.Run("decode child routes from nested tables", func(t *testing.T) {
+		config := []byte(`[[routes]]
+path_prefix = "/v1"
+
+  [routes.middleware.logging]
+
+  [[routes.routes]]
+  path = "/users"
+  method = "GET"
+
+    [[routes.routes.handlers]]
+    weight = 1
+    response_status = 200
+`)
+
+		routes, err := New().Load(bytes.NewReader(config))
+		if err != nil {
+			t.Fatalf(errFmt, nil, err)
+		}
+
+		if len(routes) != 1 || len(routes[0].Routes) != 1 {
+			t.Fatalf(errFmt, 1, routes)
+		}
+
+		if child := routes[0].Routes[0]; child.Path != "/users" || child.Source != routes[0].Source {
+			t.Errorf(errFmt, "/users", child)
+		}
+	})
+}
+
+func TestMatchersShould(t *testing.T) {
+	t.Run("decode structured matchers from inline tables", func(t *testing.T) {
+		config := []byte(`[[routes]]
+path = "/test"
+method = "GET"
+request_headers = { authorization = { regex = "^Bearer .+" }, x-debug = { present = true, not = true } }
+
+  [[routes.handlers]]
+  weight = 1
+  response_status = 200
+`)
+
+		routes, err := New().Load(bytes.NewReader(config))
+		if err != nil {
+			t.Fatalf(errFmt, nil, err)
+		}
+
+		expected := map[string]router.Matcher{
+			"authorization": {Regex: "^Bearer .+"},
+			"x-debug":       {Present: true, Not: true},
+		}
+		if !reflect.DeepEqual(expected, routes[0].RequestHeaders) {
+			t.Errorf(errFmt, expected, routes[0].RequestHeaders)
+		}
+	})
+
+	t.Run("decode body matchers with arbitrary json", func(t *testing.T) {
+		config := []byte(`[[routes]]
+path = "/events"
+method = "POST"
+
+  [routes.body.json]
+  type = "order"
+  items = [{ id = 1 }]
+
+  [routes.body.json_path]
+  "$.total" = { regex = "^[0-9]+$" }
+
+  [[routes.handlers]]
+  weight = 1
+  response_status = 201
+`)
+
+		routes, err := New().Load(bytes.NewReader(config))
+		if err != nil {
+			t.Fatalf(errFmt, nil, err)
+		}
+
+		if routes[0].Body == nil || routes[0].Body.JSON == nil {
+			t.Errorf(errFmt, "a json body matcher", routes[0].Body)
+		}
+	})
+
+	t.Run("reject unknown matcher fields", func(t *testing.T) {
+		config := []byte(`[[routes]]
+path = "/test"
+method = "GET"
+request_headers = { authorization = { regx = "^Bearer .+" } }
+`)
+
+		_, err := New().Load(bytes.NewReader(config))
+		if err == nil {
+			t.Errorf(errFmt, "an error", err)
+		}
+	})
+}
+
+func TestEnvironmentInterpolationShould(t *testing.T) {
+	os.Setenv("MOCKSERVER_TEST_TOKEN", `ab"c'`)
+	defer os.Unsetenv("MOCKSERVER_TEST_TOKEN")
+
+	t.Run("escape values substituted within basic strings", func(t *testing.T) {
+		config := []byte(`# a comment mentioning "${MOCKSERVER_TEST_TOKEN}
+[[routes]]
+path = '/token'
+method = ["GET"]
+[[routes.handlers]]
+weight = ${MOCKSERVER_TEST_WEIGHT:-1}
+static_response = "${MOCKSERVER_TEST_TOKEN}"
+response_headers = {x-token = """${MOCKSERVER_TEST_TOKEN}"""}
+response_status = 200
+`)
+
+		routes, err := New().Load(bytes.NewReader(config))
+		if err != nil {
+			t.Fatalf(errFmt, nil, err)
+		}
+
+		h := routes[0].Handlers[0]
+		if h.StaticResponse != `ab"c'` {
+			t.Errorf(errFmt, `ab"c'`, h.StaticResponse)
+		}
+
+		if v := h.ResponseHeaders["x-token"]; v != `ab"c'` {
+			t.Errorf(errFmt, `ab"c'`, v)
+		}
+
+		if h.Weight != 1 {
+			t.Errorf(errFmt, 1, h.Weight)
+		}
+	})
+
+	t.Run("return an error for values that would end a literal string", func(t *testing.T) {
+		config := []byte(`[[routes]]
+path = '/token'
+method = ["GET"]
+[[routes.handlers]]
+weight = 1
+static_response = '${MOCKSERVER_TEST_TOKEN}'
+response_status = 200
+`)
+
+		_, err := New().Load(bytes.NewReader(config))
+		if e, ok := err.(router.ErrInvalidConfig); !ok || e.Line != 6 {
+			t.Errorf(errFmt, router.ErrInvalidConfig{Line: 6}, err)
+		}
+	})
+}
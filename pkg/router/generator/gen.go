@@ -1,3 +1,4 @@
+//go:build ignore
 // +build ignore
 
 // This program generates a function map for templates. It can be invoked by
@@ -4,27 +4,39 @@ import (
 	"html/template"
 	"net/http"
 	"os"
+	"strings"
 
 	"github.com/gorilla/mux"
 	"github.com/leekchan/gtf"
 	"github.com/ncatelli/mockserver/pkg/router/generator"
+	"github.com/ncatelli/mockserver/pkg/state"
 )
 
 type templateVariables struct {
 	Request  *http.Request
 	PathVars map[string]string
+	HostVars map[string]string
 }
 
 // Generate plugins
 //go:generate go run ./generator/gen.go
 
-// Handler includes all the metadata to decide on and serve a response.
+// Handler includes all the metadata to decide on and serve a response. A
+// Handler with a When condition is only selected for requests satisfying the
+// condition, in which case its Weight is ignored. A Handler belonging to a
+// Scenario with a RequiredState is likewise only selected while the scenario
+// is in that state, and serving a Handler with a NewState transitions its
+// scenario to the new state.
 type Handler struct {
-	Weight          uint              `yaml:"weight"`
-	ResponseHeaders map[string]string `yaml:"response_headers"`
-	StaticResponse  string            `yaml:"static_response"`
-	ResponseStatus  int               `yaml:"response_status"`
-	ResponsePath    string            `yaml:"response_path"`
+	Weight          uint              `yaml:"weight,omitempty" json:"weight,omitempty" toml:"weight,omitzero"`
+	ResponseHeaders map[string]string `yaml:"response_headers,omitempty" json:"response_headers,omitempty" toml:"response_headers,omitempty"`
+	StaticResponse  string            `yaml:"static_response,omitempty" json:"static_response,omitempty" toml:"static_response,omitempty"`
+	ResponseStatus  int               `yaml:"response_status,omitempty" json:"response_status,omitempty" toml:"response_status,omitzero"`
+	ResponsePath    string            `yaml:"response_path,omitempty" json:"response_path,omitempty" toml:"response_path,omitempty"`
+	When            *Condition        `yaml:"when,omitempty" json:"when,omitempty" toml:"when,omitempty"`
+	Scenario        string            `yaml:"scenario,omitempty" json:"scenario,omitempty" toml:"scenario,omitempty"`
+	RequiredState   string            `yaml:"required_state,omitempty" json:"required_state,omitempty" toml:"required_state,omitempty"`
+	NewState        string            `yaml:"new_state,omitempty" json:"new_state,omitempty" toml:"new_state,omitempty"`
 	bodyTemplate    *template.Template
 }
 
@@ -72,9 +84,104 @@ func (handler *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set(h, v)
 	}
 
+	if len(handler.Scenario) > 0 && len(handler.NewState) > 0 {
+		state.DefaultScenarios.Set(handler.Scenario, handler.NewState)
+	}
+
 	w.WriteHeader(handler.ResponseStatus)
-	t.Execute(w, &templateVariables{
+	t.Execute(w, newTemplateVariables(r))
+}
+
+// conditional returns true if the handler is only selected for requests
+// satisfying a condition.
+func (handler *Handler) conditional() bool {
+	return handler.When != nil || len(handler.RequiredState) > 0
+}
+
+// compileCondition returns a function reporting whether a request satisfies
+// the handler's When condition and whether its scenario is in the required
+// state.
+func (handler *Handler) compileCondition() (func(*http.Request) bool, error) {
+	match := func(*http.Request) bool { return true }
+	if handler.When != nil {
+		var err error
+		if match, err = handler.When.compile(); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(handler.RequiredState) == 0 {
+		return match, nil
+	}
+
+	scenario, required := handler.Scenario, handler.RequiredState
+	return func(r *http.Request) bool {
+		return state.DefaultScenarios.State(scenario) == required && match(r)
+	}, nil
+}
+
+// newTemplateVariables returns the variables available to templates for a
+// request.
+func newTemplateVariables(r *http.Request) *templateVariables {
+	pathVars, hostVars := splitVars(r)
+
+	return &templateVariables{
 		Request:  r,
-		PathVars: mux.Vars(r),
-	})
+		PathVars: pathVars,
+		HostVars: hostVars,
+	}
+}
+
+// splitVars separates the variables matched by a request's route into those
+// defined by its host template and those defined by its path.
+func splitVars(r *http.Request) (map[string]string, map[string]string) {
+	vars := mux.Vars(r)
+	route := mux.CurrentRoute(r)
+	if route == nil {
+		return vars, make(map[string]string)
+	}
+
+	tpl, err := route.GetHostTemplate()
+	if err != nil {
+		return vars, make(map[string]string)
+	}
+
+	hostNames := make(map[string]bool)
+	for _, name := range templateVarNames(tpl) {
+		hostNames[name] = true
+	}
+
+	pathVars, hostVars := make(map[string]string), make(map[string]string)
+	for k, v := range vars {
+		if hostNames[k] {
+			hostVars[k] = v
+		} else {
+			pathVars[k] = v
+		}
+	}
+
+	return pathVars, hostVars
+}
+
+// templateVarNames returns the names of the variables in a mux template, such
+// as "sub" and "domain" in "{sub}.{domain:[a-z]+}.com".
+func templateVarNames(tpl string) []string {
+	var names []string
+	depth, start := 0, 0
+	for i, c := range tpl {
+		switch c {
+		case '{':
+			if depth == 0 {
+				start = i + 1
+			}
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				names = append(names, strings.SplitN(tpl[start:i], ":", 2)[0])
+			}
+		}
+	}
+
+	return names
 }
@@ -7,7 +7,6 @@ import (
 	"testing"
 
 	"github.com/gorilla/mux"
-	"gopkg.in/yaml.v2"
 )
 
 func TestHandlerUnmarshalingShould(t *testing.T) {
@@ -18,7 +17,7 @@ func TestHandlerUnmarshalingShould(t *testing.T) {
 
 		handler := Handler{}
 		rawHandler := []byte(`{"weight": 1}`)
-		err := yaml.Unmarshal(rawHandler, &handler)
+		err := unmarshalStrict(rawHandler, &handler)
 		if err != nil {
 			t.Error(err)
 		}
@@ -190,3 +189,27 @@ func TestHandlerServeHTTPMethodShould(t *testing.T) {
 		}
 	})
 }
+
+func TestHandlerTemplateVariablesShould(t *testing.T) {
+	t.Run("separate host variables from path variables", func(t *testing.T) {
+		h := &Handler{
+			StaticResponse: "{{ .HostVars.tenant }} {{ .PathVars.id }} {{ len .PathVars }}",
+			ResponseStatus: 200,
+		}
+
+		req, err := http.NewRequest("GET", "http://acme.example.com/users/7", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		router := mux.NewRouter()
+		router.Handle("/users/{id}", h).Host("{tenant:[a-z]{2,}}.example.com")
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		if expected := "acme 7 1"; rr.Body.String() != expected {
+			t.Errorf(errFmt, expected, rr.Body.String())
+		}
+	})
+}
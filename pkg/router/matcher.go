@@ -0,0 +1,229 @@
+package router
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Matcher describes a condition on the values of a request header or query
+// parameter. Exactly one of Value, Regex or Present should be set. Not
+// inverts the result of the condition, so a Matcher with Present and Not set
+// requires that the header or query parameter is absent.
+//
+// A Matcher may be unmarshaled from a plain string, which is shorthand for a
+// Value. An empty string is shorthand for Present.
+type Matcher struct {
+	Value   string `yaml:"value,omitempty" json:"value,omitempty" toml:"value,omitempty"`
+	Regex   string `yaml:"regex,omitempty" json:"regex,omitempty" toml:"regex,omitempty"`
+	Present bool   `yaml:"present,omitempty" json:"present,omitempty" toml:"present,omitempty"`
+	Not     bool   `yaml:"not,omitempty" json:"not,omitempty" toml:"not,omitempty"`
+}
+
+// matcherFields mirrors Matcher without its custom unmarshaling.
+type matcherFields Matcher
+
+// newMatcher returns the Matcher for a plain string value.
+func newMatcher(value string) Matcher {
+	if len(value) == 0 {
+		return Matcher{Present: true}
+	}
+
+	return Matcher{Value: value}
+}
+
+// exact returns true if the matcher is a plain, non-negated value match.
+func (m Matcher) exact() bool {
+	return len(m.Value) > 0 && len(m.Regex) == 0 && !m.Present && !m.Not
+}
+
+// validate returns an error describing why the matcher is invalid, or nil if
+// it is valid.
+func (m Matcher) validate() error {
+	set := 0
+	for _, s := range []bool{len(m.Value) > 0, len(m.Regex) > 0, m.Present} {
+		if s {
+			set++
+		}
+	}
+
+	if set != 1 {
+		return fmt.Errorf("exactly one of value, regex or present must be set")
+	}
+
+	_, err := m.compile()
+	return err
+}
+
+// compile returns a function reporting whether a set of values satisfies the
+// matcher. An empty set of values represents an absent header or query
+// parameter.
+func (m Matcher) compile() (func([]string) bool, error) {
+	var match func([]string) bool
+
+	switch {
+	case len(m.Regex) > 0:
+		re, err := regexp.Compile(m.Regex)
+		if err != nil {
+			return nil, err
+		}
+
+		match = func(values []string) bool {
+			for _, v := range values {
+				if re.MatchString(v) {
+					return true
+				}
+			}
+
+			return false
+		}
+	case len(m.Value) > 0:
+		match = func(values []string) bool {
+			for _, v := range values {
+				if v == m.Value {
+					return true
+				}
+			}
+
+			return false
+		}
+	default:
+		match = func(values []string) bool {
+			return len(values) > 0
+		}
+	}
+
+	if m.Not {
+		return func(values []string) bool { return !match(values) }, nil
+	}
+
+	return match, nil
+}
+
+// Describe formats the matcher for the passed key as either key=value,
+// key~regex or key alone for a presence match. Negated matchers are prefixed
+// with an exclamation mark.
+func (m Matcher) Describe(key string) string {
+	var s string
+	switch {
+	case len(m.Regex) > 0:
+		s = fmt.Sprintf("%s~%s", key, m.Regex)
+	case len(m.Value) > 0:
+		s = fmt.Sprintf("%s=%s", key, m.Value)
+	default:
+		s = key
+	}
+
+	if m.Not {
+		s = "!" + s
+	}
+
+	return s
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (m *Matcher) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var value string
+	if err := unmarshal(&value); err == nil {
+		*m = newMatcher(value)
+		return nil
+	}
+
+	return unmarshal((*matcherFields)(m))
+}
+
+// MarshalYAML implements the yaml.Marshaler interface.
+func (m Matcher) MarshalYAML() (interface{}, error) {
+	if m.exact() {
+		return m.Value, nil
+	}
+
+	return matcherFields(m), nil
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+func (m *Matcher) UnmarshalJSON(b []byte) error {
+	var value string
+	if err := json.Unmarshal(b, &value); err == nil {
+		*m = newMatcher(value)
+		return nil
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.DisallowUnknownFields()
+	return dec.Decode((*matcherFields)(m))
+}
+
+// MarshalJSON implements the json.Marshaler interface.
+func (m Matcher) MarshalJSON() ([]byte, error) {
+	if m.exact() {
+		return json.Marshal(m.Value)
+	}
+
+	return json.Marshal(matcherFields(m))
+}
+
+// UnmarshalTOML implements the toml.Unmarshaler interface.
+func (m *Matcher) UnmarshalTOML(v interface{}) error {
+	switch value := v.(type) {
+	case string:
+		*m = newMatcher(value)
+		return nil
+	case map[string]interface{}:
+		fields := Matcher{}
+		for k, v := range value {
+			var ok bool
+			switch k {
+			case "value":
+				fields.Value, ok = v.(string)
+			case "regex":
+				fields.Regex, ok = v.(string)
+			case "present":
+				fields.Present, ok = v.(bool)
+			case "not":
+				fields.Not, ok = v.(bool)
+			default:
+				return fmt.Errorf("unknown matcher field %q", k)
+			}
+
+			if !ok {
+				return fmt.Errorf("invalid type %T for matcher field %q", v, k)
+			}
+		}
+
+		*m = fields
+		return nil
+	}
+
+	return fmt.Errorf("matcher %v must be a string or a table", v)
+}
+
+// MarshalTOML implements the toml.Marshaler interface, encoding non-exact
+// matchers as inline tables.
+func (m Matcher) MarshalTOML() ([]byte, error) {
+	if m.exact() {
+		return []byte(strconv.Quote(m.Value)), nil
+	}
+
+	fields := make([]string, 0, 4)
+	if len(m.Value) > 0 {
+		fields = append(fields, "value = "+strconv.Quote(m.Value))
+	}
+
+	if len(m.Regex) > 0 {
+		fields = append(fields, "regex = "+strconv.Quote(m.Regex))
+	}
+
+	if m.Present {
+		fields = append(fields, "present = true")
+	}
+
+	if m.Not {
+		fields = append(fields, "not = true")
+	}
+
+	return []byte("{" + strings.Join(fields, ", ") + "}"), nil
+}
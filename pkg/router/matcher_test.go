@@ -0,0 +1,141 @@
+package router
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestMatcherUnmarshalingShould(t *testing.T) {
+	expected := map[string]Matcher{
+		"exact":   {Value: "ok"},
+		"any":     {Present: true},
+		"pattern": {Regex: "^Bearer .+"},
+		"absent":  {Present: true, Not: true},
+	}
+
+	t.Run("accept plain values and structured matchers from yaml", func(t *testing.T) {
+		raw := []byte(`
+exact: ok
+any: ''
+pattern:
+  regex: '^Bearer .+'
+absent:
+  present: true
+  not: true
+`)
+
+		matchers := map[string]Matcher{}
+		if err := unmarshalStrict(raw, &matchers); err != nil {
+			t.Fatalf(errFmt, nil, err)
+		}
+
+		if !reflect.DeepEqual(expected, matchers) {
+			t.Errorf(errFmt, expected, matchers)
+		}
+	})
+
+	t.Run("accept plain values and structured matchers from json", func(t *testing.T) {
+		raw := []byte(`{
+"exact": "ok",
+"any": "",
+"pattern": {"regex": "^Bearer .+"},
+"absent": {"present": true, "not": true}
+}`)
+
+		matchers := map[string]Matcher{}
+		if err := json.Unmarshal(raw, &matchers); err != nil {
+			t.Fatalf(errFmt, nil, err)
+		}
+
+		if !reflect.DeepEqual(expected, matchers) {
+			t.Errorf(errFmt, expected, matchers)
+		}
+	})
+
+	t.Run("reject unknown matcher fields", func(t *testing.T) {
+		matchers := map[string]Matcher{}
+		if err := json.Unmarshal([]byte(`{"typo": {"regx": "a"}}`), &matchers); err == nil {
+			t.Errorf(errFmt, "an error", err)
+		}
+	})
+}
+
+func TestMatcherShould(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		matcher Matcher
+		values  []string
+		match   bool
+	}{
+		{name: "match an exact value", matcher: Matcher{Value: "a"}, values: []string{"b", "a"}, match: true},
+		{name: "not match a different value", matcher: Matcher{Value: "a"}, values: []string{"b"}, match: false},
+		{name: "match a regex", matcher: Matcher{Regex: "^Bearer .+"}, values: []string{"Bearer abc"}, match: true},
+		{name: "not match a regex against an absent value", matcher: Matcher{Regex: ".*"}, values: nil, match: false},
+		{name: "match presence", matcher: Matcher{Present: true}, values: []string{""}, match: true},
+		{name: "match absence", matcher: Matcher{Present: true, Not: true}, values: nil, match: true},
+		{name: "not match a negated value", matcher: Matcher{Value: "a", Not: true}, values: []string{"a"}, match: false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			match, err := tc.matcher.compile()
+			if err != nil {
+				t.Fatalf(errFmt, nil, err)
+			}
+
+			if matched := match(tc.values); matched != tc.match {
+				t.Errorf(errFmt, tc.match, matched)
+			}
+		})
+	}
+
+	t.Run("fail to compile an invalid regex", func(t *testing.T) {
+		if _, err := (Matcher{Regex: "("}).compile(); err == nil {
+			t.Errorf(errFmt, "an error", err)
+		}
+	})
+}
+
+func TestRouterMatchersShould(t *testing.T) {
+	route := func() *Route {
+		return &Route{
+			Path:   "/test",
+			Method: Methods{"GET"},
+			RequestHeaders: map[string]Matcher{
+				"Authorization": {Regex: "^Bearer .+"},
+				"X-Debug":       {Present: true, Not: true},
+			},
+			QueryParams: map[string]Matcher{
+				"page": {Regex: `^\d+$`},
+			},
+			Handlers: []Handler{TestHandler},
+		}
+	}
+
+	for _, tc := range []struct {
+		name    string
+		headers map[string]string
+		query   string
+		match   bool
+	}{
+		{name: "match when every matcher is satisfied", headers: map[string]string{"Authorization": "Bearer abc"}, query: "page=2", match: true},
+		{name: "not match a header failing its regex", headers: map[string]string{"Authorization": "Basic abc"}, query: "page=2", match: false},
+		{name: "not match when a negated header is present", headers: map[string]string{"Authorization": "Bearer abc", "X-Debug": "1"}, query: "page=2", match: false},
+		{name: "not match a query parameter failing its regex", headers: map[string]string{"Authorization": "Bearer abc"}, query: "page=two", match: false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", "/test?"+tc.query, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for k, v := range tc.headers {
+				req.Header.Set(k, v)
+			}
+
+			if match := routerHelper(req, route()); match != tc.match {
+				t.Errorf(errFmt, tc.match, match)
+			}
+		})
+	}
+}
@@ -0,0 +1,136 @@
+package router
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// MethodAny is a special method that matches requests of any method.
+const MethodAny = "ANY"
+
+// Methods represents the HTTP methods a route matches. Methods can be
+// unmarshaled from either a single method or a list of methods and are
+// normalized to upper case.
+type Methods []string
+
+// newMethods returns the normalized methods, dropping any that are empty.
+func newMethods(methods ...string) Methods {
+	m := make(Methods, 0, len(methods))
+	for _, method := range methods {
+		if method = strings.ToUpper(strings.TrimSpace(method)); len(method) > 0 {
+			m = append(m, method)
+		}
+	}
+
+	return m
+}
+
+// Any returns true if the methods include MethodAny, in which case requests
+// of any method are matched.
+func (m Methods) Any() bool {
+	for _, method := range m {
+		if strings.EqualFold(method, MethodAny) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (m Methods) String() string {
+	return strings.Join(m, ",")
+}
+
+// value returns the methods as a single string when there's only one method,
+// otherwise as a slice, mirroring how methods are commonly written.
+func (m Methods) value() interface{} {
+	if len(m) == 1 {
+		return m[0]
+	}
+
+	return []string(m)
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface.
+func (m *Methods) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var method string
+	if err := unmarshal(&method); err == nil {
+		*m = newMethods(method)
+		return nil
+	}
+
+	var methods []string
+	if err := unmarshal(&methods); err != nil {
+		return err
+	}
+
+	*m = newMethods(methods...)
+	return nil
+}
+
+// MarshalYAML implements the yaml.Marshaler interface.
+func (m Methods) MarshalYAML() (interface{}, error) {
+	return m.value(), nil
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+func (m *Methods) UnmarshalJSON(b []byte) error {
+	var method string
+	if err := json.Unmarshal(b, &method); err == nil {
+		*m = newMethods(method)
+		return nil
+	}
+
+	var methods []string
+	if err := json.Unmarshal(b, &methods); err != nil {
+		return err
+	}
+
+	*m = newMethods(methods...)
+	return nil
+}
+
+// MarshalJSON implements the json.Marshaler interface.
+func (m Methods) MarshalJSON() ([]byte, error) {
+	return json.Marshal(m.value())
+}
+
+// UnmarshalTOML implements the toml.Unmarshaler interface.
+func (m *Methods) UnmarshalTOML(v interface{}) error {
+	switch value := v.(type) {
+	case string:
+		*m = newMethods(value)
+	case []interface{}:
+		methods := make([]string, 0, len(value))
+		for _, method := range value {
+			s, ok := method.(string)
+			if !ok {
+				return fmt.Errorf("method %v must be a string", method)
+			}
+
+			methods = append(methods, s)
+		}
+
+		*m = newMethods(methods...)
+	default:
+		return fmt.Errorf("method %v must be a string or a list of strings", v)
+	}
+
+	return nil
+}
+
+// MarshalTOML implements the toml.Marshaler interface.
+func (m Methods) MarshalTOML() ([]byte, error) {
+	if len(m) == 1 {
+		return []byte(strconv.Quote(m[0])), nil
+	}
+
+	quoted := make([]string, 0, len(m))
+	for _, method := range m {
+		quoted = append(quoted, strconv.Quote(method))
+	}
+
+	return []byte("[" + strings.Join(quoted, ", ") + "]"), nil
+}
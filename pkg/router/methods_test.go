@@ -0,0 +1,56 @@
+package router
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestMethodsUnmarshalingShould(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		unmarshal func([]byte, interface{}) error
+		raw       string
+		expected  Methods
+	}{
+		{name: "accept a single yaml method", unmarshal: unmarshalStrict, raw: `method: get`, expected: Methods{"GET"}},
+		{name: "accept a yaml list of methods", unmarshal: unmarshalStrict, raw: `method: [GET, head]`, expected: Methods{"GET", "HEAD"}},
+		{name: "accept a single json method", unmarshal: json.Unmarshal, raw: `{"method": "put"}`, expected: Methods{"PUT"}},
+		{name: "accept a json list of methods", unmarshal: json.Unmarshal, raw: `{"method": ["PUT", "PATCH"]}`, expected: Methods{"PUT", "PATCH"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			route := Route{}
+			if err := tc.unmarshal([]byte(tc.raw), &route); err != nil {
+				t.Fatalf(errFmt, nil, err)
+			}
+
+			if !reflect.DeepEqual(tc.expected, route.Method) {
+				t.Errorf(errFmt, tc.expected, route.Method)
+			}
+		})
+	}
+
+	t.Run("return an error on a non-string method", func(t *testing.T) {
+		route := Route{}
+		if err := json.Unmarshal([]byte(`{"method": {"GET": true}}`), &route); err == nil {
+			t.Errorf(errFmt, "an error", err)
+		}
+	})
+}
+
+func TestMethodsShould(t *testing.T) {
+	t.Run("match any method when ANY is included", func(t *testing.T) {
+		if !(Methods{"GET", MethodAny}).Any() {
+			t.Errorf(errFmt, true, false)
+		}
+	})
+
+	t.Run("marshal a single method as a scalar", func(t *testing.T) {
+		b, err := json.Marshal(Methods{"GET"})
+		if err != nil {
+			t.Fatal(err)
+		} else if string(b) != `"GET"` {
+			t.Errorf(errFmt, `"GET"`, string(b))
+		}
+	})
+}
@@ -8,12 +8,12 @@ import (
 )
 
 var (
-	middlewares = make(map[string]Middleware)
+	middlewares = make(map[string]func() Middleware)
 )
 
 func init() {
-	middlewares["logging"] = &logging.Middleware{}
-	middlewares["latency"] = &latency.Middleware{}
+	Register("logging", func() Middleware { return &logging.Middleware{} })
+	Register("latency", func() Middleware { return &latency.Middleware{} })
 }
 
 // Middleware defines the necessary functions to configure and implement a
@@ -23,11 +23,18 @@ type Middleware interface {
 	Middleware(http.Handler) http.Handler
 }
 
-// Lookup takes an id and attempts to return the corresponding middleware if
-// the middleware is undefined nil is returned.
+// Register makes a middleware available by the provided id. If Register is
+// called twice with the same id, the latter registration replaces the former.
+func Register(id string, factory func() Middleware) {
+	middlewares[id] = factory
+}
+
+// Lookup takes an id and attempts to return a new instance of the
+// corresponding middleware, so that each route configures its own instance.
+// If the middleware is undefined nil is returned.
 func Lookup(id string) Middleware {
-	if m, prs := middlewares[id]; prs == true {
-		return m
+	if f, prs := middlewares[id]; prs == true {
+		return f()
 	}
 
 	return nil
@@ -22,7 +22,7 @@ func (tm *testMiddleware) Middleware(next http.Handler) http.Handler {
 func TestMiddlewareLookupShould(t *testing.T) {
 	t.Run("return a middleware if it exists in the map", func(t *testing.T) {
 		em := &testMiddleware{}
-		middlewares["test"] = em
+		Register("test", func() Middleware { return &testMiddleware{} })
 		defer delete(middlewares, "test")
 
 		if m := Lookup("test"); m == nil {
@@ -30,6 +30,12 @@ func TestMiddlewareLookupShould(t *testing.T) {
 		}
 	})
 
+	t.Run("return a new instance of the middleware on each lookup", func(t *testing.T) {
+		if a, b := Lookup("latency"), Lookup("latency"); a == b {
+			t.Errorf(errFmt, "distinct instances", "the same instance")
+		}
+	})
+
 	t.Run("return nil if the middleware isn't registered in the map", func(t *testing.T) {
 		if m := Lookup("test_middleware_shouldn't_exist"); m != nil {
 			t.Errorf(errFmt, nil, m)
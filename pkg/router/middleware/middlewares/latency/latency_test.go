@@ -99,7 +99,8 @@ func TestLatencyMiddlewareShould(t *testing.T) {
 				defer wg.Done()
 				req, err := http.NewRequest("GET", "/", nil)
 				if err != nil {
-					t.Fatal(err)
+					t.Error(err)
+					return
 				}
 
 				router := mux.NewRouter()
@@ -167,7 +168,8 @@ func TestLatencyMiddlewareShould(t *testing.T) {
 				defer wg.Done()
 				req, err := http.NewRequest("GET", "/", nil)
 				if err != nil {
-					t.Fatal(err)
+					t.Error(err)
+					return
 				}
 
 				router := mux.NewRouter()
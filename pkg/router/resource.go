@@ -0,0 +1,180 @@
+package router
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/ncatelli/mockserver/pkg/state"
+)
+
+// resourceIDVar is the path variable identifying an item of a resource. The
+// name is reserved so that it can't collide with the variables of a resource
+// nested beneath another path variable, e.g. /users/{id}/posts.
+const resourceIDVar = "__resource_id"
+
+// defaultIDField is the field identifying the items of a resource when no
+// IDField is configured.
+const defaultIDField = "id"
+
+// Resource describes an in-memory collection of JSON objects that a route
+// serves create, read, update and delete requests against. Requests to the
+// route's path list and create items, while requests to the route's path
+// followed by an item's ID fetch, replace, update and delete the item.
+type Resource struct {
+	// Name identifies the collection. Routes declaring a resource of the same
+	// name share the collection's items.
+	Name string `yaml:"name,omitempty" json:"name,omitempty" toml:"name,omitempty"`
+
+	// IDField is the field of each item holding its ID, defaulting to "id".
+	IDField string `yaml:"id_field,omitempty" json:"id_field,omitempty" toml:"id_field,omitempty"`
+
+	// Seed is the path to a file holding a JSON array of the items the
+	// collection is created with.
+	Seed string `yaml:"seed,omitempty" json:"seed,omitempty" toml:"seed,omitempty"`
+}
+
+// idField returns the field of each item holding its ID.
+func (res *Resource) idField() string {
+	if len(res.IDField) > 0 {
+		return res.IDField
+	}
+
+	return defaultIDField
+}
+
+// seedItems reads the items the collection is created with.
+func (res *Resource) seedItems() ([]state.Item, error) {
+	if len(res.Seed) == 0 {
+		return nil, nil
+	}
+
+	b, err := os.ReadFile(res.Seed)
+	if err != nil {
+		return nil, err
+	}
+
+	var items []state.Item
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	if err := dec.Decode(&items); err != nil {
+		return nil, fmt.Errorf("seed %s must be a JSON array of objects: %v", res.Seed, err)
+	}
+
+	return items, nil
+}
+
+// validate returns an error describing why the resource is invalid, or nil if
+// it is valid.
+func (res *Resource) validate() error {
+	if len(res.Name) == 0 {
+		return errors.New("name is required")
+	}
+
+	_, err := res.seedItems()
+	return err
+}
+
+// resourceItemPath returns the path of an item of a resource served at path.
+func resourceItemPath(path string) string {
+	return strings.TrimSuffix(path, "/") + "/{" + resourceIDVar + "}"
+}
+
+// resourceHandler serves create, read, update and delete requests against a
+// collection.
+type resourceHandler struct {
+	collection *state.Collection
+}
+
+func (h resourceHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	id, isItem := mux.Vars(r)[resourceIDVar]
+
+	switch {
+	case !isItem && r.Method == http.MethodGet:
+		writeResource(w, http.StatusOK, h.collection.List())
+	case !isItem && r.Method == http.MethodPost:
+		body, ok := readItem(w, r)
+		if !ok {
+			return
+		}
+
+		item, err := h.collection.Insert(body)
+		if err != nil {
+			writeResource(w, http.StatusConflict, map[string]string{"error": err.Error()})
+			return
+		}
+
+		w.Header().Set("Location", resourceLocation(r.URL.Path, item, h.collection))
+		writeResource(w, http.StatusCreated, item)
+	case isItem && r.Method == http.MethodGet:
+		if item, prs := h.collection.Get(id); prs {
+			writeResource(w, http.StatusOK, item)
+		} else {
+			writeNotFound(w, id)
+		}
+	case isItem && (r.Method == http.MethodPut || r.Method == http.MethodPatch):
+		body, ok := readItem(w, r)
+		if !ok {
+			return
+		}
+
+		update := h.collection.Replace
+		if r.Method == http.MethodPatch {
+			update = h.collection.Update
+		}
+
+		if item, prs := update(id, body); prs {
+			writeResource(w, http.StatusOK, item)
+		} else {
+			writeNotFound(w, id)
+		}
+	case isItem && r.Method == http.MethodDelete:
+		if h.collection.Delete(id) {
+			w.WriteHeader(http.StatusNoContent)
+		} else {
+			writeNotFound(w, id)
+		}
+	default:
+		allow := "GET, POST"
+		if isItem {
+			allow = "GET, PUT, PATCH, DELETE"
+		}
+
+		w.Header().Set("Allow", allow)
+		writeResource(w, http.StatusMethodNotAllowed, map[string]string{"error": fmt.Sprintf("method %s not allowed", r.Method)})
+	}
+}
+
+// readItem decodes a JSON object from the request body, responding with a
+// 400 if the body isn't a JSON object.
+func readItem(w http.ResponseWriter, r *http.Request) (state.Item, bool) {
+	var item state.Item
+	dec := json.NewDecoder(r.Body)
+	dec.UseNumber()
+	if err := dec.Decode(&item); err != nil || item == nil {
+		writeResource(w, http.StatusBadRequest, map[string]string{"error": "request body must be a JSON object"})
+		return nil, false
+	}
+
+	return item, true
+}
+
+// resourceLocation returns the path of a newly created item.
+func resourceLocation(path string, item state.Item, c *state.Collection) string {
+	return strings.TrimSuffix(path, "/") + "/" + c.ID(item)
+}
+
+func writeNotFound(w http.ResponseWriter, id string) {
+	writeResource(w, http.StatusNotFound, map[string]string{"error": fmt.Sprintf("no item with id %s", id)})
+}
+
+func writeResource(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
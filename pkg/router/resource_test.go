@@ -0,0 +1,172 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRouterResourceShould(t *testing.T) {
+	routes := []*Route{
+		{
+			Path:     "/users",
+			Resource: &Resource{Name: "resource-test-users", Seed: "test_fixtures/users.json"},
+		},
+	}
+
+	router, err := New(routes)
+	if err != nil {
+		t.Fatalf(errFmt, nil, err)
+	}
+	defer func() {
+		for _, r := range routes {
+			r.Close()
+		}
+	}()
+
+	for _, tc := range []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		status int
+		resp   string
+	}{
+		{name: "list seeded items", method: "GET", path: "/users", status: 200, resp: `[{"id":1,"name":"alice"},{"id":2,"name":"bob"}]`},
+		{name: "fetch an item", method: "GET", path: "/users/2", status: 200, resp: `{"id":2,"name":"bob"}`},
+		{name: "create an item with a generated id", method: "POST", path: "/users", body: `{"name": "carol"}`, status: 201, resp: `{"id":3,"name":"carol"}`},
+		{name: "reject creating an item with an existing id", method: "POST", path: "/users", body: `{"id": 1}`, status: 409},
+		{name: "reject a body that isn't an object", method: "POST", path: "/users", body: `[]`, status: 400},
+		{name: "replace an item", method: "PUT", path: "/users/3", body: `{"name": "dave"}`, status: 200, resp: `{"id":3,"name":"dave"}`},
+		{name: "update an item", method: "PATCH", path: "/users/3", body: `{"admin": true}`, status: 200, resp: `{"admin":true,"id":3,"name":"dave"}`},
+		{name: "delete an item", method: "DELETE", path: "/users/3", status: 204},
+		{name: "respond with a 404 for unknown items", method: "GET", path: "/users/3", status: 404},
+		{name: "respond with a 405 for unsupported methods", method: "DELETE", path: "/users", status: 405},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body))
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			if rr.Code != tc.status {
+				t.Errorf(errFmt, tc.status, rr.Code)
+			}
+
+			if body := strings.TrimSpace(rr.Body.String()); len(tc.resp) > 0 && body != tc.resp {
+				t.Errorf(errFmt, tc.resp, body)
+			}
+		})
+	}
+
+	t.Run("locate created items", func(t *testing.T) {
+		req := httptest.NewRequest("POST", "/users", strings.NewReader(`{"id": "erin"}`))
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		if location := rr.Header().Get("Location"); location != "/users/erin" {
+			t.Errorf(errFmt, "/users/erin", location)
+		}
+	})
+
+	t.Run("apply the route's method matchers", func(t *testing.T) {
+		readOnly := []*Route{{Path: "/items", Method: Methods{http.MethodGet}, Resource: &Resource{Name: "resource-test-items"}}}
+		router, err := New(readOnly)
+		if err != nil {
+			t.Fatalf(errFmt, nil, err)
+		}
+		defer readOnly[0].Close()
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("POST", "/items", strings.NewReader(`{}`)))
+		if rr.Code != http.StatusMethodNotAllowed {
+			t.Errorf(errFmt, http.StatusMethodNotAllowed, rr.Code)
+		}
+	})
+}
+
+func TestRouterNestedResourceShould(t *testing.T) {
+	routes := []*Route{
+		{
+			Path:     "/users/{id}/posts",
+			Resource: &Resource{Name: "resource-test-posts"},
+		},
+	}
+
+	router, err := New(routes)
+	if err != nil {
+		t.Fatalf(errFmt, nil, err)
+	}
+	defer func() {
+		for _, r := range routes {
+			r.Close()
+		}
+	}()
+
+	for _, tc := range []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		status int
+		resp   string
+	}{
+		{name: "list items beneath a path variable", method: "GET", path: "/users/1/posts", status: 200, resp: `[]`},
+		{name: "create an item beneath a path variable", method: "POST", path: "/users/1/posts", body: `{"title": "hello"}`, status: 201, resp: `{"id":1,"title":"hello"}`},
+		{name: "fetch an item beneath a path variable", method: "GET", path: "/users/7/posts/1", status: 200, resp: `{"id":1,"title":"hello"}`},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body))
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			if rr.Code != tc.status {
+				t.Errorf(errFmt, tc.status, rr.Code)
+			}
+
+			if body := strings.TrimSpace(rr.Body.String()); len(tc.resp) > 0 && body != tc.resp {
+				t.Errorf(errFmt, tc.resp, body)
+			}
+		})
+	}
+}
+
+func TestRouterResourceDeclarationShould(t *testing.T) {
+	t.Run("leave the collection unchanged when the routes fail to build", func(t *testing.T) {
+		routes := []*Route{
+			{
+				Path:     "/users",
+				Resource: &Resource{Name: "resource-test-declared-users", Seed: "test_fixtures/users.json"},
+			},
+		}
+
+		router, err := New(routes)
+		if err != nil {
+			t.Fatalf(errFmt, nil, err)
+		}
+		defer routes[0].Close()
+
+		rejected := []*Route{
+			{
+				Path:     "/users",
+				Resource: &Resource{Name: "resource-test-declared-users", IDField: "name", Seed: "test_fixtures/users.json"},
+			},
+			{
+				Path:       "/broken",
+				Method:     Methods{"GET"},
+				Middleware: map[string]map[string]string{"latency": {"latency": "abc"}},
+				Handlers:   []Handler{{Weight: 1, ResponseStatus: 200}},
+			},
+		}
+
+		if _, err := New(rejected); err == nil {
+			t.Fatalf(errFmt, "an error", err)
+		}
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("GET", "/users/2", nil))
+		if rr.Code != 200 {
+			t.Errorf(errFmt, 200, rr.Code)
+		}
+	})
+}
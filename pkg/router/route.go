@@ -4,8 +4,31 @@ import (
 	"fmt"
 	"math"
 	"net/http"
+	"sort"
+	"strings"
 
 	"github.com/ncatelli/mockserver/pkg/router/middleware"
+	"github.com/ncatelli/mockserver/pkg/state"
+)
+
+// Selection modes determine how a route selects between its handlers.
+const (
+	// SelectionWeighted selects handlers in proportion to their weights.
+	SelectionWeighted = "weighted"
+
+	// SelectionSequence serves handlers in the order they're declared, with
+	// each handler serving as many consecutive requests as its weight.
+	SelectionSequence = "sequence"
+)
+
+// Sequence ends determine which handler serves requests once a sequence has
+// been exhausted.
+const (
+	// SequenceEndLast continues serving the last handler in the sequence.
+	SequenceEndLast = "last"
+
+	// SequenceEndLoop restarts the sequence from the first handler.
+	SequenceEndLoop = "loop"
 )
 
 // ErrInvalidWeight is thrown when a handler has a weight outside the
@@ -18,6 +41,31 @@ func (e ErrInvalidWeight) Error() string {
 	return fmt.Sprintf("handler %v exceeds maximum total weight of %v", *e.handler, math.MaxInt64)
 }
 
+// ErrInvalidRoute wraps an error encountered while initializing a route with
+// the method and path of the route that failed.
+type ErrInvalidRoute struct {
+	Method string
+	Path   string
+	Err    error
+}
+
+func (e ErrInvalidRoute) Error() string {
+	return fmt.Sprintf("route %s %s: %v", e.Method, e.Path, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e ErrInvalidRoute) Unwrap() error {
+	return e.Err
+}
+
+// ErrNoRoutableHandlers is thrown when a route has no handlers with either a
+// non-zero weight or a when condition.
+type ErrNoRoutableHandlers struct{}
+
+func (e ErrNoRoutableHandlers) Error() string {
+	return "route has no handlers with a non-zero weight or a when condition"
+}
+
 // StrideHandlers wraps the Handler type with a precomputed stride and pass context.
 type StrideHandler struct {
 	pass    uint
@@ -29,38 +77,201 @@ func (sH *StrideHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	sH.handler.ServeHTTP(w, r)
 }
 
+// conditionalHandler pairs a handler with its compiled When condition.
+type conditionalHandler struct {
+	match   func(*http.Request) bool
+	handler *Handler
+}
+
 // Route includes all routing data to build a route and forward to an
 // appropriate router. This is handed off to the router for the live routing.
+// A route with a PathPrefix or child Routes is a group, which serves no
+// requests itself and instead shares its prefix, matchers and middleware with
+// each of its child routes.
 type Route struct {
-	Path               string                       `yaml:"path"`
-	Method             string                       `yaml:"method"`
-	QueryParams        map[string]string            `yaml:"query_params"`
-	RequestHeaders     map[string]string            `yaml:"request_headers"`
-	Middleware         map[string]map[string]string `yaml:"middleware"`
-	Handlers           []Handler                    `yaml:"handlers"`
+	Path string `yaml:"path,omitempty" json:"path,omitempty" toml:"path,omitempty"`
+
+	// PathPrefix is the path shared by each of a group's child routes.
+	PathPrefix string `yaml:"path_prefix,omitempty" json:"path_prefix,omitempty" toml:"path_prefix,omitempty"`
+
+	// Priority orders the routes a request is matched against, with routes of
+	// a higher priority matched first.
+	Priority int `yaml:"priority,omitempty" json:"priority,omitempty" toml:"priority,omitzero"`
+
+	// Fallback marks a route that defines no matchers and instead serves any
+	// request that doesn't match another route.
+	Fallback bool `yaml:"fallback,omitempty" json:"fallback,omitempty" toml:"fallback,omitempty"`
+
+	Method         Methods                      `yaml:"method,omitempty" json:"method,omitempty" toml:"method,omitempty"`
+	Host           string                       `yaml:"host,omitempty" json:"host,omitempty" toml:"host,omitempty"`
+	Scheme         string                       `yaml:"scheme,omitempty" json:"scheme,omitempty" toml:"scheme,omitempty"`
+	QueryParams    map[string]Matcher           `yaml:"query_params,omitempty" json:"query_params,omitempty" toml:"query_params,omitempty"`
+	RequestHeaders map[string]Matcher           `yaml:"request_headers,omitempty" json:"request_headers,omitempty" toml:"request_headers,omitempty"`
+	Cookies        map[string]Matcher           `yaml:"cookies,omitempty" json:"cookies,omitempty" toml:"cookies,omitempty"`
+	Body           *BodyMatcher                 `yaml:"body,omitempty" json:"body,omitempty" toml:"body,omitempty"`
+	Middleware     map[string]map[string]string `yaml:"middleware,omitempty" json:"middleware,omitempty" toml:"middleware,omitempty"`
+
+	// Selection determines how handlers are selected between, defaulting to
+	// SelectionWeighted.
+	Selection string `yaml:"selection,omitempty" json:"selection,omitempty" toml:"selection,omitempty"`
+
+	// SequenceEnd determines how a SelectionSequence continues once every
+	// handler has been served, defaulting to SequenceEndLast.
+	SequenceEnd string    `yaml:"sequence_end,omitempty" json:"sequence_end,omitempty" toml:"sequence_end,omitempty"`
+	Handlers    []Handler `yaml:"handlers,omitempty" json:"handlers,omitempty" toml:"handlers,omitempty"`
+
+	// Resource, when set, serves requests against the resource's collection
+	// in place of handlers.
+	Resource *Resource `yaml:"resource,omitempty" json:"resource,omitempty" toml:"resource,omitempty"`
+
+	// Routes are the child routes of a group.
+	Routes []*Route `yaml:"routes,omitempty" json:"routes,omitempty" toml:"routes,omitempty"`
+
+	// Source and Line optionally record the file or URL, and the line within
+	// it, that the route was loaded from.
+	Source string `yaml:"-" json:"-" toml:"-"`
+	Line   int    `yaml:"-" json:"-" toml:"-"`
+
 	middlewareHandlers []middleware.Middleware
+	conditional        []conditionalHandler
+	resource           http.Handler
+	seed               []state.Item
+	weighted           bool
 	handlerChan        chan http.Handler
+	done               chan struct{}
 }
 
-// Init performs any setup and initialization around the route.
+// MiddlewareChain returns the names of the route's middleware in the order
+// they wrap the handler, from outermost to innermost. Middleware are applied
+// in lexical order of their names.
+func (route *Route) MiddlewareChain() []string {
+	names := make([]string, 0, len(route.Middleware))
+	for k := range route.Middleware {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// IsGroup returns true if the route is a group of child routes rather than a
+// route that serves requests.
+func (route *Route) IsGroup() bool {
+	return len(route.PathPrefix) > 0 || len(route.Routes) > 0
+}
+
+// declare declares the collection of the route's resource, or of the
+// resource of each route within a group, in the shared state. Declaring a
+// collection replaces its seed and may re-key its items, so routes are only
+// declared once every route has been initialized successfully, leaving the
+// state untouched by a configuration that fails to build.
+func (route *Route) declare() {
+	for _, r := range Flatten([]*Route{route}) {
+		if r.Resource != nil {
+			collection := state.DefaultCollections.Declare(r.Resource.Name, r.Resource.idField(), r.seed)
+			r.resource = resourceHandler{collection: collection}
+		}
+	}
+}
+
+// Flatten returns every route that serves requests, descending into groups in
+// the order their child routes are declared.
+func Flatten(routes []*Route) []*Route {
+	flattened := make([]*Route, 0, len(routes))
+	for _, r := range routes {
+		if r.IsGroup() {
+			flattened = append(flattened, Flatten(r.Routes)...)
+		} else {
+			flattened = append(flattened, r)
+		}
+	}
+
+	return flattened
+}
+
+// Init performs any setup and initialization around the route. Initializing
+// a group initializes each of its child routes, with each child inheriting
+// the group's middleware unless it configures middleware of the same name.
 func (route *Route) Init() error {
+	if route.IsGroup() {
+		return route.initGroup()
+	}
+
 	route.handlerChan = make(chan http.Handler, 1024)
+	route.done = make(chan struct{})
 
-	for k, v := range route.Middleware {
+	for _, k := range route.MiddlewareChain() {
 		m := middleware.Lookup(k)
 		if m == nil {
 			return middleware.ErrUndefinedMiddleware{ID: k}
 		}
 
-		if err := m.Init(v); err != nil {
+		if err := m.Init(route.Middleware[k]); err != nil {
 			return err
 		}
 
 		route.middlewareHandlers = append(route.middlewareHandlers, m)
 	}
 
-	go func(handler []Handler, middlewareHandlers []middleware.Middleware, handlerQueue chan http.Handler) {
-		middlewareCount := len(middlewareHandlers)
+	if route.Resource != nil {
+		items, err := route.Resource.seedItems()
+		if err != nil {
+			return err
+		}
+
+		// the collection is declared by declare once every route has been
+		// initialized.
+		route.seed = items
+		return nil
+	}
+
+	// compile each handler's template up front so that invalid templates and
+	// unreadable response files are caught prior to serving.
+	for i := range route.Handlers {
+		if _, err := route.Handlers[i].getBodyTemplate(); err != nil {
+			return err
+		}
+	}
+
+	// conditional handlers, including those requiring a scenario state, are
+	// tried in order ahead of weighted selection, while handlers with a
+	// weight of zero are unroutable and excluded from selection.
+	route.conditional = nil
+	routable := make([]Handler, 0, len(route.Handlers))
+	for i := range route.Handlers {
+		h := &route.Handlers[i]
+		if len(h.Scenario) > 0 {
+			state.DefaultScenarios.Register(h.Scenario)
+		}
+
+		if h.conditional() {
+			match, err := h.compileCondition()
+			if err != nil {
+				return err
+			}
+
+			route.conditional = append(route.conditional, conditionalHandler{match: match, handler: h})
+		} else if h.Weight > 0 {
+			routable = append(routable, *h)
+		}
+	}
+
+	if len(routable) == 0 && len(route.conditional) == 0 {
+		return ErrNoRoutableHandlers{}
+	}
+
+	route.weighted = len(routable) > 0
+	if !route.weighted {
+		return nil
+	}
+
+	if strings.EqualFold(route.Selection, SelectionSequence) {
+		loop := strings.EqualFold(route.SequenceEnd, SequenceEndLoop)
+		go sequence(routable, loop, route.middlewareHandlers, route.handlerChan, route.done)
+		return nil
+	}
+
+	go func(handler []Handler, middlewareHandlers []middleware.Middleware, handlerQueue chan http.Handler, done chan struct{}) {
 		handlerCount := len(handler)
 		strideHandlers := make([]*StrideHandler, 0, handlerCount)
 
@@ -110,26 +321,141 @@ func (route *Route) Init() error {
 			// incrememt pass by stride
 			sH.pass += sH.stride
 
-			var middlewareHandler http.Handler = sH
-			// Generate handler chain with middlewares
-			for i := middlewareCount; i > 0; i-- {
-				middlewareHandler = middlewareHandlers[i].Middleware(sH)
+			select {
+			case handlerQueue <- chain(sH, middlewareHandlers):
+			case <-done:
+				return
+			}
+		}
+	}(routable, route.middlewareHandlers, route.handlerChan, route.done)
+
+	return nil
+}
+
+func (route *Route) initGroup() error {
+	for i, child := range route.Routes {
+		inherited := make(map[string]map[string]string, len(route.Middleware)+len(child.Middleware))
+		for k, v := range route.Middleware {
+			inherited[k] = v
+		}
+
+		for k, v := range child.Middleware {
+			inherited[k] = v
+		}
+
+		if len(inherited) > 0 {
+			child.Middleware = inherited
+		}
+
+		if err := child.Init(); err != nil {
+			// release any child routes that have already been initialized.
+			for _, initialized := range route.Routes[:i] {
+				initialized.Close()
+			}
+
+			// errors from nested groups already identify the failing route,
+			// so only the group's prefix is added to its path.
+			if invalid, ok := err.(ErrInvalidRoute); ok && child.IsGroup() {
+				invalid.Path = route.PathPrefix + invalid.Path
+				return invalid
 			}
 
-			// Generate handler chain with middlewares
-			handlerQueue <- middlewareHandler
+			return ErrInvalidRoute{Method: child.Method.String(), Path: route.PathPrefix + child.Path, Err: err}
 		}
-	}(route.Handlers, route.middlewareHandlers, route.handlerChan)
+	}
 
 	return nil
 }
 
+// sequence queues each handler in the order they're declared, repeating each
+// handler as many times as its weight. Once every handler has been queued,
+// the sequence either restarts or continues to queue the last handler.
+func sequence(handlers []Handler, loop bool, middlewareHandlers []middleware.Middleware, handlerQueue chan http.Handler, done chan struct{}) {
+	for {
+		for i := range handlers {
+			for n := uint(0); n < handlers[i].Weight; n++ {
+				select {
+				case handlerQueue <- chain(&handlers[i], middlewareHandlers):
+				case <-done:
+					return
+				}
+			}
+		}
+
+		if !loop {
+			break
+		}
+	}
+
+	last := &handlers[len(handlers)-1]
+	for {
+		select {
+		case handlerQueue <- chain(last, middlewareHandlers):
+		case <-done:
+			return
+		}
+	}
+}
+
+// chain wraps a handler with each middleware, wrapping in reverse so that the
+// first middleware is the outermost.
+func chain(h http.Handler, middlewareHandlers []middleware.Middleware) http.Handler {
+	for i := len(middlewareHandlers) - 1; i >= 0; i-- {
+		h = middlewareHandlers[i].Middleware(h)
+	}
+
+	return h
+}
+
 // ServeHTTP implements the http.Handler interface for pipelining a request
-// further into a handler.
+// further into a handler. Routes with a resource serve every request against
+// the resource's collection. Otherwise the first conditional handler whose
+// condition the request satisfies is served, otherwise a handler is selected
+// by weight. A route without weighted handlers responds with a 404 to
+// requests that don't satisfy any condition.
 func (route *Route) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	handler := <-route.handlerChan
+	if route.resource != nil {
+		chain(route.resource, route.middlewareHandlers).ServeHTTP(w, r)
+		return
+	}
 
-	handler.ServeHTTP(w, r)
+	for _, c := range route.conditional {
+		if c.match(r) {
+			chain(c.handler, route.middlewareHandlers).ServeHTTP(w, r)
+			return
+		}
+	}
+
+	if !route.weighted {
+		select {
+		case <-route.done:
+			http.Error(w, "", http.StatusServiceUnavailable)
+		default:
+			http.NotFound(w, r)
+		}
+
+		return
+	}
+
+	select {
+	case handler := <-route.handlerChan:
+		handler.ServeHTTP(w, r)
+	case <-route.done:
+		http.Error(w, "", http.StatusServiceUnavailable)
+	}
+}
+
+// Close stops the handler selection for an initialized route, releasing any
+// resources started by Init. A closed route responds to any further requests
+// with a 503. Closing a group closes each of its child routes.
+func (route *Route) Close() {
+	for _, child := range route.Routes {
+		child.Close()
+	}
+
+	if route.done != nil {
+		close(route.done)
+	}
 }
 
 func gcd(a, b uint) uint {
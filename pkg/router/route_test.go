@@ -1,25 +1,27 @@
 package router
 
 import (
+	"bytes"
 	"net/http"
 	"net/http/httptest"
 	"reflect"
 	"testing"
 
 	"github.com/gorilla/mux"
-	"gopkg.in/yaml.v2"
+	"github.com/ncatelli/mockserver/pkg/router/middleware/middlewares/latency"
+	"gopkg.in/yaml.v3"
 )
 
 func TestRouteUnmarshalingShould(t *testing.T) {
 	t.Run("unmarshal to the correct keys", func(t *testing.T) {
 		expectedRoute := Route{
 			Path:   "/",
-			Method: "GET",
+			Method: Methods{"GET"},
 		}
 
 		route := Route{}
 		rawRoute := []byte(`{"path": "/", "method": "GET"}`)
-		err := yaml.Unmarshal(rawRoute, &route)
+		err := unmarshalStrict(rawRoute, &route)
 		if err != nil {
 			t.Error(err)
 		}
@@ -45,7 +47,7 @@ func TestHandlerSelectionShould(t *testing.T) {
 	t.Run("return handlers in deterministic pattern for unequally-weighted handlers", func(t *testing.T) {
 		r := &Route{
 			Path:     "/",
-			Method:   "GET",
+			Method:   Methods{"GET"},
 			Handlers: []Handler{successHandler, failureHandler},
 		}
 		r.Init()
@@ -83,7 +85,7 @@ func TestHandlerSelectionShould(t *testing.T) {
 		equalSuccessHandler.Weight = 1
 		r := &Route{
 			Path:     "/",
-			Method:   "GET",
+			Method:   Methods{"GET"},
 			Handlers: []Handler{equalSuccessHandler, failureHandler},
 		}
 		r.Init()
@@ -117,6 +119,242 @@ func TestHandlerSelectionShould(t *testing.T) {
 	})
 }
 
+func TestSequenceSelectionShould(t *testing.T) {
+	serve := func(t *testing.T, r *Route, n int) []int {
+		if err := r.Init(); err != nil {
+			t.Fatalf(errFmt, nil, err)
+		}
+		defer r.Close()
+
+		codes := make([]int, 0, n)
+		for i := 0; i < n; i++ {
+			req, err := http.NewRequest("GET", "/", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+			codes = append(codes, rr.Code)
+		}
+
+		return codes
+	}
+
+	handlers := func() []Handler {
+		return []Handler{
+			{Weight: 2, ResponseStatus: 503},
+			{Weight: 0, ResponseStatus: 500},
+			{Weight: 1, ResponseStatus: 200},
+		}
+	}
+
+	t.Run("serve handlers in order, sticking on the last by default", func(t *testing.T) {
+		r := &Route{Path: "/", Method: Methods{"GET"}, Selection: SelectionSequence, Handlers: handlers()}
+
+		expected := []int{503, 503, 200, 200, 200}
+		if codes := serve(t, r, 5); !reflect.DeepEqual(expected, codes) {
+			t.Errorf(errFmt, expected, codes)
+		}
+	})
+
+	t.Run("restart the sequence when looping", func(t *testing.T) {
+		r := &Route{Path: "/", Method: Methods{"GET"}, Selection: SelectionSequence, SequenceEnd: SequenceEndLoop, Handlers: handlers()}
+
+		expected := []int{503, 503, 200, 503, 503, 200}
+		if codes := serve(t, r, 6); !reflect.DeepEqual(expected, codes) {
+			t.Errorf(errFmt, expected, codes)
+		}
+	})
+}
+
+func TestZeroWeightedHandlersShould(t *testing.T) {
+	t.Run("never be selected", func(t *testing.T) {
+		r := &Route{
+			Path:   "/",
+			Method: Methods{"GET"},
+			Handlers: []Handler{
+				{Weight: 0, ResponseStatus: 500},
+				{Weight: 1, ResponseStatus: 200, StaticResponse: "Ok"},
+			},
+		}
+		if err := r.Init(); err != nil {
+			t.Fatalf(errFmt, nil, err)
+		}
+		defer r.Close()
+
+		for i := 0; i < 10; i++ {
+			req, err := http.NewRequest("GET", "/", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+			if rr.Code != http.StatusOK {
+				t.Errorf(errFmt, http.StatusOK, rr.Code)
+			}
+		}
+	})
+
+	t.Run("return an error from Init when every handler has a zero weight", func(t *testing.T) {
+		r := &Route{
+			Path:     "/",
+			Method:   Methods{"GET"},
+			Handlers: []Handler{{Weight: 0, ResponseStatus: 500}},
+		}
+
+		if err := r.Init(); err == nil {
+			t.Errorf(errFmt, ErrNoRoutableHandlers{}, err)
+		}
+	})
+}
+
+func TestRouteMiddlewareShould(t *testing.T) {
+	t.Run("wrap the selected handler", func(t *testing.T) {
+		r := &Route{
+			Path:       "/",
+			Method:     Methods{"GET"},
+			Middleware: map[string]map[string]string{"latency": {"latency": "0"}},
+			Handlers:   []Handler{TestHandler},
+		}
+		if err := r.Init(); err != nil {
+			t.Fatalf(errFmt, nil, err)
+		}
+		defer r.Close()
+
+		req, err := http.NewRequest("GET", "/", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+		if rr.Code != TestHandler.ResponseStatus {
+			t.Errorf(errFmt, TestHandler.ResponseStatus, rr.Code)
+		}
+	})
+}
+
+func TestRouteMiddlewareChainShould(t *testing.T) {
+	t.Run("order middleware by name", func(t *testing.T) {
+		r := &Route{
+			Middleware: map[string]map[string]string{"logging": {}, "latency": {}},
+		}
+
+		expected := []string{"latency", "logging"}
+		if chain := r.MiddlewareChain(); !reflect.DeepEqual(expected, chain) {
+			t.Errorf(errFmt, expected, chain)
+		}
+	})
+}
+
+func TestRouteGroupShould(t *testing.T) {
+	t.Run("inherit the group's middleware", func(t *testing.T) {
+		child := &Route{
+			Path:       "/users",
+			Method:     Methods{"GET"},
+			Middleware: map[string]map[string]string{"logging": {}},
+			Handlers:   []Handler{TestHandler},
+		}
+		group := &Route{
+			PathPrefix: "/v1",
+			Middleware: map[string]map[string]string{"latency": {"latency": "0"}},
+			Routes:     []*Route{child},
+		}
+		if err := group.Init(); err != nil {
+			t.Fatalf(errFmt, nil, err)
+		}
+		defer group.Close()
+
+		expected := []string{"latency", "logging"}
+		if chain := child.MiddlewareChain(); !reflect.DeepEqual(expected, chain) {
+			t.Errorf(errFmt, expected, chain)
+		}
+	})
+
+	t.Run("configure the middleware of each child independently", func(t *testing.T) {
+		fast := &Route{
+			Path:       "/fast",
+			Method:     Methods{"GET"},
+			Middleware: map[string]map[string]string{"latency": {"latency": "1"}},
+			Handlers:   []Handler{TestHandler},
+		}
+		slow := &Route{Path: "/slow", Method: Methods{"GET"}, Handlers: []Handler{TestHandler}}
+		group := &Route{
+			PathPrefix: "/v1",
+			Middleware: map[string]map[string]string{"latency": {"latency": "300"}},
+			Routes:     []*Route{fast, slow},
+		}
+		if err := group.Init(); err != nil {
+			t.Fatalf(errFmt, nil, err)
+		}
+		defer group.Close()
+
+		for expected, child := range map[int]*Route{1: fast, 300: slow} {
+			if l := child.middlewareHandlers[0].(*latency.Middleware).Latency; l != expected {
+				t.Errorf(errFmt, expected, l)
+			}
+		}
+	})
+
+	t.Run("identify the child route that failed to initialize", func(t *testing.T) {
+		group := &Route{
+			PathPrefix: "/v1",
+			Routes: []*Route{
+				{PathPrefix: "/users", Routes: []*Route{{Path: "/{id}", Method: Methods{"GET"}, Handlers: []Handler{{Weight: 0}}}}},
+			},
+		}
+
+		expected := ErrInvalidRoute{Method: "GET", Path: "/v1/users/{id}", Err: ErrNoRoutableHandlers{}}
+		if err := group.Init(); !reflect.DeepEqual(expected, err) {
+			t.Errorf(errFmt, expected, err)
+		}
+	})
+}
+
+func TestFlattenShould(t *testing.T) {
+	t.Run("return the routes within groups in declaration order", func(t *testing.T) {
+		a, b, c := &Route{Path: "/a"}, &Route{Path: "/b"}, &Route{Path: "/c"}
+		routes := []*Route{a, {PathPrefix: "/v1", Routes: []*Route{b, {Routes: []*Route{c}}}}}
+
+		expected := []*Route{a, b, c}
+		if flattened := Flatten(routes); !reflect.DeepEqual(expected, flattened) {
+			t.Errorf(errFmt, expected, flattened)
+		}
+	})
+}
+
+func TestRouteCloseShould(t *testing.T) {
+	t.Run("respond with a 503 once the handler queue is drained", func(t *testing.T) {
+		r := &Route{
+			Path:     "/",
+			Method:   Methods{"GET"},
+			Handlers: []Handler{TestHandler},
+		}
+		r.Init()
+		r.Close()
+
+		// any handlers queued prior to closing are still served, so request
+		// until the queue has been exhausted.
+		code := http.StatusOK
+		for i := 0; i <= cap(r.handlerChan)+1 && code != http.StatusServiceUnavailable; i++ {
+			req, err := http.NewRequest("GET", "/", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+			code = rr.Code
+		}
+
+		if code != http.StatusServiceUnavailable {
+			t.Errorf(errFmt, http.StatusServiceUnavailable, code)
+		}
+	})
+}
+
 func BenchmarkRouterHandlerSelectionWith(b *testing.B) {
 	successHandler := Handler{
 		Weight:         1,
@@ -131,7 +369,7 @@ func BenchmarkRouterHandlerSelectionWith(b *testing.B) {
 	b.Run("equally-weighted handlers", func(b *testing.B) {
 		r := &Route{
 			Path:     "/",
-			Method:   "GET",
+			Method:   Methods{"GET"},
 			Handlers: []Handler{successHandler, failureHandler},
 		}
 		r.Init()
@@ -147,7 +385,7 @@ func BenchmarkRouterHandlerSelectionWith(b *testing.B) {
 		unequalSuccessHandler.Weight = 2
 		r := &Route{
 			Path:     "/",
-			Method:   "GET",
+			Method:   Methods{"GET"},
 			Handlers: []Handler{unequalSuccessHandler, failureHandler},
 		}
 		r.Init()
@@ -159,3 +397,11 @@ func BenchmarkRouterHandlerSelectionWith(b *testing.B) {
 	})
 
 }
+
+// unmarshalStrict decodes yaml as the simple driver does, rejecting unknown
+// fields.
+func unmarshalStrict(b []byte, v interface{}) error {
+	dec := yaml.NewDecoder(bytes.NewReader(b))
+	dec.KnownFields(true)
+	return dec.Decode(v)
+}
@@ -1,29 +1,200 @@
 package router
 
 import (
+	"net/http"
+	"sort"
+	"strings"
+
 	"github.com/gorilla/mux"
 )
 
 // New takes a list of routes and attempts to return a router with all of these
-// routes registered to it.
+// routes registered to it. Routes are validated prior to registration and an
+// ErrValidation describing every problem found is returned if any are
+// invalid. Routes are registered in the order returned by Ordered, with any
+// fallback route serving requests that don't match any other route.
 func New(routes []*Route) (*mux.Router, error) {
+	if err := Validate(routes); err != nil {
+		return nil, err
+	}
+
 	m := mux.NewRouter()
 
-	for _, r := range routes {
+	for i, r := range routes {
 		if err := r.Init(); err != nil {
-			return nil, err
+			// release any routes that have already been initialized.
+			for _, initialized := range routes[:i] {
+				initialized.Close()
+			}
+
+			if r.IsGroup() {
+				return nil, err
+			}
+
+			return nil, ErrInvalidRoute{Method: r.Method.String(), Path: r.Path, Err: err}
 		}
+	}
 
-		route := m.Handle(r.Path, r).Methods(r.Method)
+	for _, r := range routes {
+		r.declare()
+	}
+
+	register(m, routes)
+
+	return m, nil
+}
 
-		for k, v := range r.RequestHeaders {
-			route.Headers(k, v)
+// register adds each route to the router in the order returned by Ordered.
+// Groups are registered as a subrouter matching the group's path prefix and
+// matchers, with the group's child routes registered to the subrouter.
+// Resources are registered at both their path and the path of their items.
+func register(m *mux.Router, routes []*Route) {
+	for _, r := range Ordered(routes) {
+		if r.Fallback {
+			m.NotFoundHandler = r
+			m.MethodNotAllowedHandler = r
+			continue
 		}
 
-		for k, v := range r.QueryParams {
-			route.Queries(k, v)
+		var routes []*mux.Route
+		switch {
+		case len(r.PathPrefix) > 0:
+			routes = append(routes, m.PathPrefix(r.PathPrefix))
+		case r.IsGroup():
+			routes = append(routes, m.NewRoute())
+		case r.Resource != nil:
+			routes = append(routes, m.Handle(r.Path, r), m.Handle(resourceItemPath(r.Path), r))
+		default:
+			routes = append(routes, m.Handle(r.Path, r))
+		}
+
+		for _, route := range routes {
+			addMatchers(route, r)
+		}
+
+		if r.IsGroup() {
+			register(routes[0].Subrouter(), r.Routes)
 		}
 	}
+}
 
-	return m, nil
+// addMatchers registers each of a route's request matchers against a mux
+// route.
+func addMatchers(route *mux.Route, r *Route) {
+	if len(r.Method) > 0 && !r.Method.Any() {
+		route.Methods(r.Method...)
+	}
+
+	if len(r.Host) > 0 {
+		route.Host(r.Host)
+	}
+
+	if len(r.Scheme) > 0 {
+		scheme := strings.ToLower(r.Scheme)
+		route.MatcherFunc(func(r *http.Request, _ *mux.RouteMatch) bool {
+			return requestScheme(r) == scheme
+		})
+	}
+
+	addHeaderMatchers(route, r.RequestHeaders)
+	addQueryMatchers(route, r.QueryParams)
+	addCookieMatchers(route, r.Cookies)
+	if r.Body != nil {
+		addBodyMatcher(route, r.Body)
+	}
+}
+
+// Ordered returns the routes in the order they're matched against a request,
+// by descending priority. Routes of equal priority retain the order they were
+// declared in.
+func Ordered(routes []*Route) []*Route {
+	ordered := append([]*Route(nil), routes...)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return ordered[i].Priority > ordered[j].Priority
+	})
+
+	return ordered
+}
+
+// addHeaderMatchers registers each header matcher against a route. Exact
+// matches are delegated to mux, otherwise the matcher is evaluated against
+// every value of the header.
+func addHeaderMatchers(route *mux.Route, matchers map[string]Matcher) {
+	for k, m := range matchers {
+		if m.exact() {
+			route.Headers(k, m.Value)
+			continue
+		}
+
+		key := k
+		match, _ := m.compile()
+		route.MatcherFunc(func(r *http.Request, _ *mux.RouteMatch) bool {
+			return match(r.Header.Values(key))
+		})
+	}
+}
+
+// addQueryMatchers registers each query parameter matcher against a route.
+// Exact matches are delegated to mux, allowing the use of mux's query
+// templates, otherwise the matcher is evaluated against every value of the
+// query parameter.
+func addQueryMatchers(route *mux.Route, matchers map[string]Matcher) {
+	for k, m := range matchers {
+		if m.exact() {
+			route.Queries(k, m.Value)
+			continue
+		}
+
+		key := k
+		match, _ := m.compile()
+		route.MatcherFunc(func(r *http.Request, _ *mux.RouteMatch) bool {
+			return match(r.URL.Query()[key])
+		})
+	}
+}
+
+// addCookieMatchers registers each cookie matcher against a route. Matchers
+// are evaluated against the values of every cookie with a matching name.
+func addCookieMatchers(route *mux.Route, matchers map[string]Matcher) {
+	for k, m := range matchers {
+		name := k
+		match, _ := m.compile()
+		route.MatcherFunc(func(r *http.Request, _ *mux.RouteMatch) bool {
+			var values []string
+			for _, c := range r.Cookies() {
+				if c.Name == name {
+					values = append(values, c.Value)
+				}
+			}
+
+			return match(values)
+		})
+	}
+}
+
+// requestScheme returns the scheme a request was made with. Requests that
+// have passed through a proxy are identified by their X-Forwarded-Proto
+// header, otherwise the scheme is determined by whether the request was
+// served over TLS.
+func requestScheme(r *http.Request) string {
+	if len(r.URL.Scheme) > 0 {
+		return strings.ToLower(r.URL.Scheme)
+	} else if proto := r.Header.Get("X-Forwarded-Proto"); len(proto) > 0 {
+		return strings.ToLower(proto)
+	} else if r.TLS != nil {
+		return "https"
+	}
+
+	return "http"
+}
+
+// addBodyMatcher registers a body matcher against a route. The request body
+// is restored after matching so it remains readable by later matchers and
+// handlers.
+func addBodyMatcher(route *mux.Route, b *BodyMatcher) {
+	match, _ := b.compile()
+	route.MatcherFunc(func(r *http.Request, _ *mux.RouteMatch) bool {
+		body, err := readBody(r)
+		return err == nil && match(body)
+	})
 }
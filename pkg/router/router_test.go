@@ -2,7 +2,9 @@ package router
 
 import (
 	"net/http"
+	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/gorilla/mux"
 )
@@ -29,7 +31,7 @@ func TestRouterShouldMatch(t *testing.T) {
 
 		route := &Route{
 			Path:     "/test",
-			Method:   "GET",
+			Method:   Methods{"GET"},
 			Handlers: []Handler{TestHandler},
 		}
 
@@ -46,7 +48,7 @@ func TestRouterShouldMatch(t *testing.T) {
 
 		route := &Route{
 			Path:     "/test/{key}",
-			Method:   "GET",
+			Method:   Methods{"GET"},
 			Handlers: []Handler{TestHandler},
 		}
 
@@ -65,9 +67,9 @@ func TestRouterShouldMatch(t *testing.T) {
 
 		route := &Route{
 			Path:   "/test",
-			Method: "GET",
-			RequestHeaders: map[string]string{
-				"TestHeader": "present",
+			Method: Methods{"GET"},
+			RequestHeaders: map[string]Matcher{
+				"TestHeader": {Value: "present"},
 			},
 			Handlers: []Handler{TestHandler},
 		}
@@ -85,9 +87,9 @@ func TestRouterShouldMatch(t *testing.T) {
 
 		route := &Route{
 			Path:   "/test",
-			Method: "GET",
-			QueryParams: map[string]string{
-				"testparam": "present",
+			Method: Methods{"GET"},
+			QueryParams: map[string]Matcher{
+				"testparam": {Value: "present"},
 			},
 			Handlers: []Handler{TestHandler},
 		}
@@ -98,6 +100,36 @@ func TestRouterShouldMatch(t *testing.T) {
 	})
 }
 
+func TestRouterMethodMatchingShould(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		methods Methods
+		method  string
+		match   bool
+	}{
+		{name: "match any listed method", methods: Methods{"GET", "HEAD"}, method: "HEAD", match: true},
+		{name: "not match an unlisted method", methods: Methods{"GET", "HEAD"}, method: "POST", match: false},
+		{name: "match every method with ANY", methods: Methods{MethodAny}, method: "DELETE", match: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			req, err := http.NewRequest(tc.method, "/test", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			route := &Route{
+				Path:     "/test",
+				Method:   tc.methods,
+				Handlers: []Handler{TestHandler},
+			}
+
+			if match := routerHelper(req, route); match != tc.match {
+				t.Errorf(errFmt, tc.match, match)
+			}
+		})
+	}
+}
+
 func TestRouterShouldNotMatch(t *testing.T) {
 	t.Run("not match when a route with a valid path but invalid method are specified", func(t *testing.T) {
 		req, err := http.NewRequest("GET", "/test", nil)
@@ -107,7 +139,7 @@ func TestRouterShouldNotMatch(t *testing.T) {
 
 		route := &Route{
 			Path:     "/test",
-			Method:   "POST",
+			Method:   Methods{"POST"},
 			Handlers: []Handler{TestHandler},
 		}
 
@@ -124,9 +156,9 @@ func TestRouterShouldNotMatch(t *testing.T) {
 
 		route := &Route{
 			Path:   "/test",
-			Method: "GET",
-			RequestHeaders: map[string]string{
-				"TestHeader": "present",
+			Method: Methods{"GET"},
+			RequestHeaders: map[string]Matcher{
+				"TestHeader": {Value: "present"},
 			},
 			Handlers: []Handler{TestHandler},
 		}
@@ -144,9 +176,9 @@ func TestRouterShouldNotMatch(t *testing.T) {
 
 		route := &Route{
 			Path:   "/test",
-			Method: "GET",
-			QueryParams: map[string]string{
-				"testparam": "present",
+			Method: Methods{"GET"},
+			QueryParams: map[string]Matcher{
+				"testparam": {Value: "present"},
 			},
 			Handlers: []Handler{TestHandler},
 		}
@@ -156,3 +188,246 @@ func TestRouterShouldNotMatch(t *testing.T) {
 		}
 	})
 }
+
+func TestRouterShouldReturnAnError(t *testing.T) {
+	t.Run("when a handler template fails to parse", func(t *testing.T) {
+		route := &Route{
+			Path:   "/test",
+			Method: Methods{"GET"},
+			Handlers: []Handler{
+				{Weight: 1, ResponseStatus: 200, StaticResponse: "{{ .PathVars"},
+			},
+		}
+
+		_, err := New([]*Route{route})
+		if _, ok := err.(ErrValidation); !ok {
+			t.Errorf(errFmt, ErrValidation{}, err)
+		}
+	})
+
+	t.Run("when a handler response file doesn't exist", func(t *testing.T) {
+		route := &Route{
+			Path:   "/test",
+			Method: Methods{"GET"},
+			Handlers: []Handler{
+				{Weight: 1, ResponseStatus: 200, ResponsePath: "test_fixtures/this_file_should_not_exist.txt"},
+			},
+		}
+
+		_, err := New([]*Route{route})
+		if _, ok := err.(ErrValidation); !ok {
+			t.Errorf(errFmt, ErrValidation{}, err)
+		}
+	})
+}
+
+func TestRouterRequestMatchersShould(t *testing.T) {
+	route := func() *Route {
+		return &Route{
+			Path:     "/test",
+			Method:   Methods{"GET"},
+			Host:     "{tenant}.example.com",
+			Scheme:   "HTTPS",
+			Cookies:  map[string]Matcher{"session": {Regex: "^[a-f0-9]+$"}},
+			Handlers: []Handler{TestHandler},
+		}
+	}
+
+	for _, tc := range []struct {
+		name    string
+		url     string
+		headers map[string]string
+		cookie  string
+		match   bool
+	}{
+		{name: "match when every matcher is satisfied", url: "https://acme.example.com/test", cookie: "abc123", match: true},
+		{name: "match a scheme forwarded by a proxy", url: "/test", headers: map[string]string{"Host": "acme.example.com", "X-Forwarded-Proto": "https"}, cookie: "abc123", match: true},
+		{name: "not match another host", url: "https://acme.example.org/test", cookie: "abc123", match: false},
+		{name: "not match another scheme", url: "http://acme.example.com/test", cookie: "abc123", match: false},
+		{name: "not match a cookie failing its matcher", url: "https://acme.example.com/test", cookie: "xyz", match: false},
+		{name: "not match a missing cookie", url: "https://acme.example.com/test", match: false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", tc.url, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for k, v := range tc.headers {
+				if k == "Host" {
+					req.Host = v
+					continue
+				}
+
+				req.Header.Set(k, v)
+			}
+
+			if len(tc.cookie) > 0 {
+				req.AddCookie(&http.Cookie{Name: "session", Value: tc.cookie})
+			}
+
+			if match := routerHelper(req, route()); match != tc.match {
+				t.Errorf(errFmt, tc.match, match)
+			}
+		})
+	}
+}
+
+func TestRouterPriorityShould(t *testing.T) {
+	serve := func(t *testing.T, routes []*Route, method, path string) *httptest.ResponseRecorder {
+		router, err := New(routes)
+		if err != nil {
+			t.Fatalf(errFmt, nil, err)
+		}
+		t.Cleanup(func() {
+			for _, r := range routes {
+				r.Close()
+			}
+		})
+
+		req, err := http.NewRequest(method, path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	t.Run("match routes with a higher priority first", func(t *testing.T) {
+		routes := []*Route{
+			{Path: "/users/{id}", Method: Methods{"GET"}, Handlers: []Handler{{Weight: 1, ResponseStatus: 200}}},
+			{Path: "/users/me", Method: Methods{"GET"}, Priority: 10, Handlers: []Handler{{Weight: 1, ResponseStatus: 202}}},
+		}
+
+		if rr := serve(t, routes, "GET", "/users/me"); rr.Code != 202 {
+			t.Errorf(errFmt, 202, rr.Code)
+		}
+	})
+
+	t.Run("keep declaration order for equal priorities", func(t *testing.T) {
+		routes := []*Route{
+			{Path: "/users/{id}", Method: Methods{"GET"}, Handlers: []Handler{{Weight: 1, ResponseStatus: 200}}},
+			{Path: "/users/me", Method: Methods{"GET"}, Handlers: []Handler{{Weight: 1, ResponseStatus: 202}}},
+		}
+
+		if rr := serve(t, routes, "GET", "/users/me"); rr.Code != 200 {
+			t.Errorf(errFmt, 200, rr.Code)
+		}
+	})
+
+	for _, tc := range []struct {
+		name   string
+		method string
+		path   string
+	}{
+		{name: "serve unmatched paths with the fallback route", method: "GET", path: "/missing"},
+		{name: "serve unmatched methods with the fallback route", method: "DELETE", path: "/test"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			routes := []*Route{
+				{Path: "/test", Method: Methods{"GET"}, Handlers: []Handler{TestHandler}},
+				{Fallback: true, Handlers: []Handler{{Weight: 1, ResponseStatus: 404, StaticResponse: `{"error": "no route for {{ .Request.URL.Path }}"}`}}},
+			}
+
+			rr := serve(t, routes, tc.method, tc.path)
+			if rr.Code != 404 {
+				t.Errorf(errFmt, 404, rr.Code)
+			}
+
+			if expected := `{"error": "no route for ` + tc.path + `"}`; rr.Body.String() != expected {
+				t.Errorf(errFmt, expected, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestRouterGroupsShould(t *testing.T) {
+	routes := []*Route{
+		{
+			PathPrefix:     "/v1",
+			RequestHeaders: map[string]Matcher{"Authorization": {Present: true}},
+			Routes: []*Route{
+				{Path: "/users", Method: Methods{"GET"}, Handlers: []Handler{{Weight: 1, ResponseStatus: 200}}},
+				{PathPrefix: "/admin", Routes: []*Route{
+					{Path: "/stats", Method: Methods{"GET"}, Handlers: []Handler{{Weight: 1, ResponseStatus: 202}}},
+				}},
+			},
+		},
+		{Path: "/v1/health", Method: Methods{"GET"}, Handlers: []Handler{{Weight: 1, ResponseStatus: 204}}},
+	}
+
+	router, err := New(routes)
+	if err != nil {
+		t.Fatalf(errFmt, nil, err)
+	}
+	defer func() {
+		for _, r := range routes {
+			r.Close()
+		}
+	}()
+
+	for _, tc := range []struct {
+		name          string
+		path          string
+		authorization bool
+		expected      int
+	}{
+		{name: "prefix child routes with the group's path", path: "/v1/users", authorization: true, expected: 200},
+		{name: "prefix routes of nested groups with each group's path", path: "/v1/admin/stats", authorization: true, expected: 202},
+		{name: "apply the group's matchers to child routes", path: "/v1/users", expected: 404},
+		{name: "fall through to later routes when no child route matches", path: "/v1/health", authorization: true, expected: 204},
+		{name: "not match child routes without the prefix", path: "/users", authorization: true, expected: 404},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", tc.path, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if tc.authorization {
+				req.Header.Set("Authorization", "Bearer token")
+			}
+
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+			if rr.Code != tc.expected {
+				t.Errorf(errFmt, tc.expected, rr.Code)
+			}
+		})
+	}
+}
+
+func TestRouterMiddlewareShould(t *testing.T) {
+	t.Run("apply each route's own middleware configuration", func(t *testing.T) {
+		routes := []*Route{
+			{Path: "/fast", Method: Methods{"GET"}, Middleware: map[string]map[string]string{"latency": {"latency": "1"}}, Handlers: []Handler{TestHandler}},
+			{Path: "/slow", Method: Methods{"GET"}, Middleware: map[string]map[string]string{"latency": {"latency": "300"}}, Handlers: []Handler{TestHandler}},
+		}
+
+		router, err := New(routes)
+		if err != nil {
+			t.Fatalf(errFmt, nil, err)
+		}
+		defer func() {
+			for _, r := range routes {
+				r.Close()
+			}
+		}()
+
+		elapsed := func(path string) time.Duration {
+			start := time.Now()
+			router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+			return time.Since(start)
+		}
+
+		if d := elapsed("/fast"); d >= 150*time.Millisecond {
+			t.Errorf(errFmt, "less than 150ms", d)
+		}
+
+		if d := elapsed("/slow"); d < 150*time.Millisecond {
+			t.Errorf(errFmt, "at least 150ms", d)
+		}
+	})
+}
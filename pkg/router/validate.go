@@ -0,0 +1,286 @@
+package router
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/ncatelli/mockserver/pkg/router/middleware"
+)
+
+// ErrInvalidConfig describes a single problem found in a route
+// configuration. When known, the source and line the problem was found at are
+// included.
+type ErrInvalidConfig struct {
+	Source string
+	Line   int
+	Reason string
+}
+
+func (e ErrInvalidConfig) Error() string {
+	location := e.Source
+	if e.Line > 0 {
+		location = fmt.Sprintf("%s:%d", location, e.Line)
+	}
+
+	if len(location) == 0 {
+		return e.Reason
+	}
+
+	return fmt.Sprintf("%s: %s", location, e.Reason)
+}
+
+// ErrValidation aggregates every problem found in a route configuration.
+type ErrValidation []ErrInvalidConfig
+
+func (e ErrValidation) Error() string {
+	problems := make([]string, 0, len(e))
+	for _, p := range e {
+		problems = append(problems, p.Error())
+	}
+
+	return strings.Join(problems, "\n")
+}
+
+// Validate performs semantic checks against each route, returning an
+// ErrValidation describing every problem found or nil if the routes are
+// valid.
+func Validate(routes []*Route) error {
+	var problems ErrValidation
+	fallbacks := 0
+	for _, r := range routes {
+		problems = append(problems, r.validate("")...)
+
+		if r.Fallback {
+			if fallbacks++; fallbacks > 1 {
+				problems = append(problems, ErrInvalidConfig{
+					Source: r.Source,
+					Line:   r.Line,
+					Reason: "route fallback: only one fallback route may be defined",
+				})
+			}
+		}
+	}
+
+	if len(problems) > 0 {
+		return problems
+	}
+
+	return nil
+}
+
+// validate returns every problem found with a route and its handlers, or
+// with a group and each of its child routes. Routes are identified by their
+// path joined to the prefix of any enclosing groups.
+func (route *Route) validate(prefix string) []ErrInvalidConfig {
+	var problems []ErrInvalidConfig
+	label := strings.TrimSpace(fmt.Sprintf("%s %s", route.Method, prefix+route.Path))
+	if route.IsGroup() {
+		label = strings.TrimSpace("group " + prefix + route.PathPrefix)
+	} else if route.Fallback {
+		label = "fallback"
+	}
+
+	problem := func(format string, a ...interface{}) {
+		problems = append(problems, ErrInvalidConfig{
+			Source: route.Source,
+			Line:   route.Line,
+			Reason: fmt.Sprintf("route %s: %s", label, fmt.Sprintf(format, a...)),
+		})
+	}
+
+	switch {
+	case route.IsGroup():
+		if route.Fallback {
+			problem("a group must not be a fallback route")
+		}
+
+		if len(route.Path) > 0 {
+			problem("a group must not define a path, use path_prefix instead")
+		}
+
+		if len(route.Handlers) > 0 || len(route.Selection) > 0 {
+			problem("a group must not define handlers or a selection")
+		}
+
+		if len(route.Routes) == 0 {
+			problem("at least one route is required")
+		}
+	case route.Fallback:
+		if route.hasMatchers() {
+			problem("a fallback route must not define a path, method or any request matchers")
+		}
+	case route.Resource != nil:
+		if len(route.Path) == 0 {
+			problem("path is required")
+		}
+
+		if len(route.Handlers) > 0 || len(route.Selection) > 0 {
+			problem("a resource must not define handlers or a selection")
+		}
+
+		if err := route.Resource.validate(); err != nil {
+			problem("resource: %v", err)
+		}
+	default:
+		if len(route.Path) == 0 {
+			problem("path is required")
+		}
+
+		if len(route.Method) == 0 {
+			problem("method is required")
+		}
+	}
+
+	for k := range route.Middleware {
+		if middleware.Lookup(k) == nil {
+			problem("%v", middleware.ErrUndefinedMiddleware{ID: k})
+		}
+	}
+
+	if len(route.Host) > 0 {
+		if err := mux.NewRouter().Host(route.Host).GetError(); err != nil {
+			problem("host %s: %v", route.Host, err)
+		}
+	}
+
+	if s := strings.ToLower(route.Scheme); len(s) > 0 && s != "http" && s != "https" {
+		problem("scheme %s must be either http or https", route.Scheme)
+	}
+
+	for _, k := range sortedKeys(route.Cookies) {
+		if err := route.Cookies[k].validate(); err != nil {
+			problem("cookies %s: %v", k, err)
+		}
+	}
+
+	for _, k := range sortedKeys(route.RequestHeaders) {
+		if err := route.RequestHeaders[k].validate(); err != nil {
+			problem("request_headers %s: %v", k, err)
+		}
+	}
+
+	for _, k := range sortedKeys(route.QueryParams) {
+		if err := route.QueryParams[k].validate(); err != nil {
+			problem("query_params %s: %v", k, err)
+		}
+	}
+
+	if route.Body != nil {
+		if err := route.Body.validate(); err != nil {
+			problem("body: %v", err)
+		}
+	}
+
+	switch strings.ToLower(route.Selection) {
+	case "", SelectionWeighted, SelectionSequence:
+	default:
+		problem("selection %s must be either %s or %s", route.Selection, SelectionWeighted, SelectionSequence)
+	}
+
+	if len(route.SequenceEnd) > 0 {
+		if !strings.EqualFold(route.Selection, SelectionSequence) {
+			problem("sequence_end requires a selection of %s", SelectionSequence)
+		} else if s := strings.ToLower(route.SequenceEnd); s != SequenceEndLast && s != SequenceEndLoop {
+			problem("sequence_end %s must be either %s or %s", route.SequenceEnd, SequenceEndLast, SequenceEndLoop)
+		}
+	}
+
+	if route.IsGroup() {
+		for _, child := range route.Routes {
+			if child.Fallback {
+				problems = append(problems, ErrInvalidConfig{
+					Source: child.Source,
+					Line:   child.Line,
+					Reason: "route fallback: a fallback route must not be defined within a group",
+				})
+			}
+
+			problems = append(problems, child.validate(prefix+route.PathPrefix)...)
+		}
+
+		return problems
+	}
+
+	if route.Resource != nil {
+		return problems
+	}
+
+	if len(route.Handlers) == 0 {
+		problem("at least one handler is required")
+	}
+
+	var totalWeight uint
+	conditional := false
+	for i := range route.Handlers {
+		h := &route.Handlers[i]
+		if h.When != nil {
+			if err := h.When.validate(); err != nil {
+				problem("handler %d: when: %v", i, err)
+			}
+		}
+
+		if len(h.Scenario) == 0 && (len(h.RequiredState) > 0 || len(h.NewState) > 0) {
+			problem("handler %d: required_state and new_state require a scenario", i)
+		} else if len(h.Scenario) > 0 && len(h.RequiredState) == 0 && len(h.NewState) == 0 {
+			problem("handler %d: scenario %s requires a required_state or new_state", i, h.Scenario)
+		}
+
+		if h.conditional() {
+			conditional = true
+		} else {
+			totalWeight += h.Weight
+		}
+
+		if h.ResponseStatus < 100 || h.ResponseStatus > 599 {
+			problem("handler %d: response_status %d is not a valid status code", i, h.ResponseStatus)
+		}
+
+		if len(h.StaticResponse) == 0 && len(h.ResponsePath) > 0 {
+			if info, err := os.Stat(h.ResponsePath); err != nil {
+				problem("handler %d: response_path %s is unreadable", i, h.ResponsePath)
+				continue
+			} else if info.IsDir() {
+				problem("handler %d: response_path %s is a directory", i, h.ResponsePath)
+				continue
+			}
+		}
+
+		if _, err := h.getBodyTemplate(); err != nil {
+			problem("handler %d: invalid response template: %v", i, err)
+		}
+	}
+
+	if len(route.Handlers) > 0 && totalWeight == 0 && !conditional {
+		problem("at least one handler must have a non-zero weight or a when condition")
+	}
+
+	return problems
+}
+
+// hasMatchers returns true if the route defines any conditions on the
+// requests it matches.
+func (route *Route) hasMatchers() bool {
+	return len(route.Path) > 0 ||
+		len(route.Method) > 0 ||
+		len(route.Host) > 0 ||
+		len(route.Scheme) > 0 ||
+		len(route.RequestHeaders) > 0 ||
+		len(route.QueryParams) > 0 ||
+		len(route.Cookies) > 0 ||
+		route.Body != nil
+}
+
+// sortedKeys returns the keys of a set of matchers in lexical order so that
+// problems are reported deterministically.
+func sortedKeys(matchers map[string]Matcher) []string {
+	keys := make([]string, 0, len(matchers))
+	for k := range matchers {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
@@ -0,0 +1,209 @@
+package router
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestValidateShould(t *testing.T) {
+	t.Run("return nil for valid routes", func(t *testing.T) {
+		route := &Route{
+			Path:     "/test",
+			Method:   Methods{"GET"},
+			Handlers: []Handler{TestHandler},
+		}
+
+		if err := Validate([]*Route{route}); err != nil {
+			t.Errorf(errFmt, nil, err)
+		}
+	})
+
+	for _, tc := range []struct {
+		name   string
+		route  Route
+		reason string
+	}{
+		{
+			name:   "a missing path",
+			route:  Route{Method: Methods{"GET"}, Handlers: []Handler{TestHandler}},
+			reason: "route GET: path is required",
+		},
+		{
+			name:   "a missing method",
+			route:  Route{Path: "/test", Handlers: []Handler{TestHandler}},
+			reason: "route /test: method is required",
+		},
+		{
+			name:   "an unknown middleware",
+			route:  Route{Path: "/test", Method: Methods{"GET"}, Middleware: map[string]map[string]string{"undefined": {}}, Handlers: []Handler{TestHandler}},
+			reason: "route GET /test: the middleware undefined is undefined",
+		},
+		{
+			name:   "no handlers",
+			route:  Route{Path: "/test", Method: Methods{"GET"}},
+			reason: "route GET /test: at least one handler is required",
+		},
+		{
+			name:   "an invalid status code",
+			route:  Route{Path: "/test", Method: Methods{"GET"}, Handlers: []Handler{{Weight: 1}}},
+			reason: "route GET /test: handler 0: response_status 0 is not a valid status code",
+		},
+		{
+			name:   "an invalid header regex",
+			route:  Route{Path: "/test", Method: Methods{"GET"}, RequestHeaders: map[string]Matcher{"Authorization": {Regex: "("}}, Handlers: []Handler{TestHandler}},
+			reason: "route GET /test: request_headers Authorization: error parsing regexp: missing closing ): `(`",
+		},
+		{
+			name:   "an ambiguous query matcher",
+			route:  Route{Path: "/test", Method: Methods{"GET"}, QueryParams: map[string]Matcher{"page": {Value: "1", Regex: "1"}}, Handlers: []Handler{TestHandler}},
+			reason: "route GET /test: query_params page: exactly one of value, regex or present must be set",
+		},
+		{
+			name:   "an unsupported scheme",
+			route:  Route{Path: "/test", Method: Methods{"GET"}, Scheme: "ftp", Handlers: []Handler{TestHandler}},
+			reason: "route GET /test: scheme ftp must be either http or https",
+		},
+		{
+			name:   "an unbalanced host template",
+			route:  Route{Path: "/test", Method: Methods{"GET"}, Host: "{tenant.example.com", Handlers: []Handler{TestHandler}},
+			reason: "route GET /test: host {tenant.example.com: mux: unbalanced braces in \"{tenant.example.com\"",
+		},
+		{
+			name:   "a fallback route with matchers",
+			route:  Route{Fallback: true, Path: "/test", Handlers: []Handler{TestHandler}},
+			reason: "route fallback: a fallback route must not define a path, method or any request matchers",
+		},
+		{
+			name:   "all zero weights",
+			route:  Route{Path: "/test", Method: Methods{"GET"}, Handlers: []Handler{{ResponseStatus: 200}}},
+			reason: "route GET /test: at least one handler must have a non-zero weight or a when condition",
+		},
+		{
+			name:   "an unreadable response file",
+			route:  Route{Path: "/test", Method: Methods{"GET"}, Handlers: []Handler{{Weight: 1, ResponseStatus: 200, ResponsePath: "test_fixtures/this_file_should_not_exist.txt"}}},
+			reason: "route GET /test: handler 0: response_path test_fixtures/this_file_should_not_exist.txt is unreadable",
+		},
+		{
+			name:   "a group without routes",
+			route:  Route{PathPrefix: "/v1"},
+			reason: "route group /v1: at least one route is required",
+		},
+		{
+			name:   "a group with handlers",
+			route:  Route{PathPrefix: "/v1", Handlers: []Handler{TestHandler}, Routes: []*Route{{Path: "/test", Method: Methods{"GET"}, Handlers: []Handler{TestHandler}}}},
+			reason: "route group /v1: a group must not define handlers or a selection",
+		},
+		{
+			name:   "a group with a path",
+			route:  Route{Path: "/v1", Routes: []*Route{{Path: "/test", Method: Methods{"GET"}, Handlers: []Handler{TestHandler}}}},
+			reason: "route group: a group must not define a path, use path_prefix instead",
+		},
+		{
+			name:   "an invalid route within a group",
+			route:  Route{PathPrefix: "/v1", Routes: []*Route{{Path: "/test", Method: Methods{"GET"}, Source: "mocks.yaml", Line: 3}}},
+			reason: "route GET /v1/test: at least one handler is required",
+		},
+		{
+			name:   "a fallback route within a group",
+			route:  Route{PathPrefix: "/v1", Routes: []*Route{{Fallback: true, Handlers: []Handler{TestHandler}, Source: "mocks.yaml", Line: 3}}},
+			reason: "route fallback: a fallback route must not be defined within a group",
+		},
+		{
+			name:   "an invalid when condition",
+			route:  Route{Path: "/test", Method: Methods{"GET"}, Handlers: []Handler{{ResponseStatus: 200, When: &Condition{QueryParams: map[string]Matcher{"page": {Regex: "("}}}}}},
+			reason: "route GET /test: handler 0: when: query_params page: error parsing regexp: missing closing ): `(`",
+		},
+		{
+			name:   "an unknown selection",
+			route:  Route{Path: "/test", Method: Methods{"GET"}, Selection: "random", Handlers: []Handler{TestHandler}},
+			reason: "route GET /test: selection random must be either weighted or sequence",
+		},
+		{
+			name:   "a sequence end without a sequence",
+			route:  Route{Path: "/test", Method: Methods{"GET"}, SequenceEnd: "loop", Handlers: []Handler{TestHandler}},
+			reason: "route GET /test: sequence_end requires a selection of sequence",
+		},
+		{
+			name:   "an unknown sequence end",
+			route:  Route{Path: "/test", Method: Methods{"GET"}, Selection: "sequence", SequenceEnd: "stop", Handlers: []Handler{TestHandler}},
+			reason: "route GET /test: sequence_end stop must be either last or loop",
+		},
+		{
+			name:   "a state transition without a scenario",
+			route:  Route{Path: "/test", Method: Methods{"GET"}, Handlers: []Handler{{Weight: 1, ResponseStatus: 200, NewState: "created"}}},
+			reason: "route GET /test: handler 0: required_state and new_state require a scenario",
+		},
+		{
+			name:   "a scenario without a state",
+			route:  Route{Path: "/test", Method: Methods{"GET"}, Handlers: []Handler{{Weight: 1, ResponseStatus: 200, Scenario: "orders"}}},
+			reason: "route GET /test: handler 0: scenario orders requires a required_state or new_state",
+		},
+		{
+			name:   "a resource without a name",
+			route:  Route{Path: "/users", Resource: &Resource{}},
+			reason: "route /users: resource: name is required",
+		},
+		{
+			name:   "a resource with handlers",
+			route:  Route{Path: "/users", Resource: &Resource{Name: "users"}, Handlers: []Handler{TestHandler}},
+			reason: "route /users: a resource must not define handlers or a selection",
+		},
+		{
+			name:   "a resource seed that isn't a JSON array",
+			route:  Route{Path: "/users", Resource: &Resource{Name: "users", Seed: "test_fixtures/good_simple_string.txt"}},
+			reason: "route /users: resource: seed test_fixtures/good_simple_string.txt must be a JSON array of objects: invalid character 'O' looking for beginning of value",
+		},
+	} {
+		t.Run("report "+tc.name, func(t *testing.T) {
+			route := tc.route
+			route.Source = "mocks.yaml"
+			route.Line = 3
+
+			expected := ErrValidation{{Source: "mocks.yaml", Line: 3, Reason: tc.reason}}
+			if err := Validate([]*Route{&route}); !reflect.DeepEqual(expected, err) {
+				t.Errorf(errFmt, expected, err)
+			}
+		})
+	}
+
+	t.Run("report every problem across all routes", func(t *testing.T) {
+		routes := []*Route{
+			{Path: "/a", Handlers: []Handler{TestHandler}},
+			{Method: Methods{"GET"}, Handlers: []Handler{TestHandler}},
+		}
+
+		err, ok := Validate(routes).(ErrValidation)
+		if !ok || len(err) != 2 {
+			t.Errorf(errFmt, 2, err)
+		}
+	})
+	t.Run("report more than one fallback route", func(t *testing.T) {
+		routes := []*Route{
+			{Fallback: true, Handlers: []Handler{TestHandler}},
+			{Fallback: true, Handlers: []Handler{TestHandler}},
+		}
+
+		expected := ErrValidation{{Reason: "route fallback: only one fallback route may be defined"}}
+		if err := Validate(routes); !reflect.DeepEqual(expected, err) {
+			t.Errorf(errFmt, expected, err)
+		}
+	})
+}
+
+func TestErrInvalidConfigShould(t *testing.T) {
+	t.Run("prefix the reason with the source and line", func(t *testing.T) {
+		expected := "mocks.yaml:3: path is required"
+		e := ErrInvalidConfig{Source: "mocks.yaml", Line: 3, Reason: "path is required"}
+		if e.Error() != expected {
+			t.Errorf(errFmt, expected, e.Error())
+		}
+	})
+
+	t.Run("omit an unknown location", func(t *testing.T) {
+		expected := "path is required"
+		e := ErrInvalidConfig{Reason: "path is required"}
+		if e.Error() != expected {
+			t.Errorf(errFmt, expected, e.Error())
+		}
+	})
+}
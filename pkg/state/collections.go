@@ -0,0 +1,302 @@
+package state
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"sync"
+)
+
+// DefaultCollections is the collection store shared by every route.
+var DefaultCollections = NewCollections()
+
+// Item is a single JSON object held by a Collection.
+type Item map[string]interface{}
+
+// ErrItemExists is returned when inserting an item with the ID of an item
+// that's already in the collection.
+type ErrItemExists struct {
+	ID string
+}
+
+func (e ErrItemExists) Error() string {
+	return fmt.Sprintf("an item with id %s already exists", e.ID)
+}
+
+// Collections tracks every named Collection. A Collections is safe for
+// concurrent use.
+type Collections struct {
+	mu          sync.Mutex
+	collections map[string]*Collection
+}
+
+// NewCollections returns an empty collection store.
+func NewCollections() *Collections {
+	return &Collections{collections: make(map[string]*Collection)}
+}
+
+// Declare returns the named collection, creating it from the seed items if it
+// doesn't already exist. An existing collection keeps its items, so that they
+// persist across reloads of the route configuration, but is reset to the
+// passed seed items from then on. If the ID field of an existing collection
+// differs, its items are identified by the passed ID field instead.
+func (s *Collections) Declare(name, idField string, seed []Item) *Collection {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if c, prs := s.collections[name]; prs {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+
+		c.seed = seed
+		if c.idField != idField {
+			items := make([]Item, 0, len(c.ids))
+			for _, id := range c.ids {
+				items = append(items, c.items[id])
+			}
+
+			c.idField = idField
+			c.fill(items)
+		}
+
+		return c
+	}
+
+	c := newCollection(idField, seed)
+	s.collections[name] = c
+	return c
+}
+
+// ResetAll resets every collection to its seed items.
+func (s *Collections) ResetAll() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, c := range s.collections {
+		c.Reset()
+	}
+}
+
+// snapshot returns a copy of the items of every collection.
+func (s *Collections) snapshot() map[string]CollectionSnapshot {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	snapshot := make(map[string]CollectionSnapshot, len(s.collections))
+	for name, c := range s.collections {
+		snapshot[name] = CollectionSnapshot{IDField: c.idField, Items: c.List()}
+	}
+
+	return snapshot
+}
+
+// restore replaces the items of each collection in the snapshot, creating
+// the collections that don't already exist. Existing collections keep their
+// ID field.
+func (s *Collections) restore(snapshot map[string]CollectionSnapshot) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for name, cs := range snapshot {
+		c, prs := s.collections[name]
+		if !prs {
+			c = newCollection(cs.IDField, nil)
+			s.collections[name] = c
+		}
+
+		c.restore(cs.Items)
+	}
+}
+
+// Collection is an ordered set of items identified by the value of their ID
+// field. A Collection is safe for concurrent use.
+type Collection struct {
+	mu      sync.RWMutex
+	idField string
+	seed    []Item
+	ids     []string
+	items   map[string]Item
+	nextID  int
+}
+
+func newCollection(idField string, seed []Item) *Collection {
+	c := &Collection{idField: idField, seed: seed}
+	c.Reset()
+
+	return c
+}
+
+// Reset replaces the items of the collection with its seed items.
+func (c *Collection) Reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.fill(c.seed)
+}
+
+// restore replaces the items of the collection without changing its seed.
+func (c *Collection) restore(items []Item) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.fill(items)
+}
+
+// fill replaces the items of the collection with copies of the passed items.
+func (c *Collection) fill(items []Item) {
+	c.ids, c.items, c.nextID = nil, make(map[string]Item), 1
+	for _, item := range items {
+		c.insert(copyItem(item))
+	}
+}
+
+// List returns every item in the order they were inserted.
+func (c *Collection) List() []Item {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	items := make([]Item, 0, len(c.ids))
+	for _, id := range c.ids {
+		items = append(items, copyItem(c.items[id]))
+	}
+
+	return items
+}
+
+// Get returns the item with the passed ID.
+func (c *Collection) Get(id string) (Item, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	item, prs := c.items[id]
+	return copyItem(item), prs
+}
+
+// Insert adds an item to the collection, generating an ID for it if it
+// doesn't have one. Generated IDs are integers, one greater than the largest
+// integer ID in the collection. The inserted item is returned.
+func (c *Collection) Insert(item Item) (Item, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	item = copyItem(item)
+	if v, prs := item[c.idField]; prs {
+		if _, exists := c.items[ItemID(v)]; exists {
+			return nil, ErrItemExists{ID: ItemID(v)}
+		}
+	}
+
+	return copyItem(c.insert(item)), nil
+}
+
+func (c *Collection) insert(item Item) Item {
+	if _, prs := item[c.idField]; !prs {
+		item[c.idField] = json.Number(strconv.Itoa(c.nextID))
+	}
+
+	id := ItemID(item[c.idField])
+	if n, err := strconv.Atoi(id); err == nil && n >= c.nextID {
+		c.nextID = n + 1
+	}
+
+	if _, prs := c.items[id]; !prs {
+		c.ids = append(c.ids, id)
+	}
+	c.items[id] = item
+
+	return item
+}
+
+// Replace replaces the item with the passed ID, keeping its ID. The
+// resulting item is returned, or false if no item has the ID.
+func (c *Collection) Replace(id string, item Item) (Item, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	existing, prs := c.items[id]
+	if !prs {
+		return nil, false
+	}
+
+	item = copyItem(item)
+	item[c.idField] = existing[c.idField]
+	c.items[id] = item
+
+	return copyItem(item), true
+}
+
+// Update merges the passed fields into the item with the passed ID, keeping
+// its ID. The resulting item is returned, or false if no item has the ID.
+func (c *Collection) Update(id string, fields Item) (Item, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	item, prs := c.items[id]
+	if !prs {
+		return nil, false
+	}
+
+	for k, v := range fields {
+		if k != c.idField {
+			item[k] = v
+		}
+	}
+
+	return copyItem(item), true
+}
+
+// Delete removes the item with the passed ID, returning false if no item has
+// the ID.
+func (c *Collection) Delete(id string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, prs := c.items[id]; !prs {
+		return false
+	}
+
+	delete(c.items, id)
+	for i, v := range c.ids {
+		if v == id {
+			c.ids = append(c.ids[:i], c.ids[i+1:]...)
+			break
+		}
+	}
+
+	return true
+}
+
+// ID returns the ID of an item of the collection.
+func (c *Collection) ID(item Item) string {
+	return ItemID(item[c.idField])
+}
+
+// ItemID formats the value of an item's ID field as used to identify the
+// item, so that the number 1 and the string "1" identify the same item.
+func ItemID(v interface{}) string {
+	switch id := v.(type) {
+	case string:
+		return id
+	case json.Number:
+		return id.String()
+	case float64:
+		return strconv.FormatFloat(id, 'f', -1, 64)
+	}
+
+	return fmt.Sprint(v)
+}
+
+// copyItem returns a shallow copy of an item, so that items held by a
+// collection can't be modified outside of it.
+func copyItem(item Item) Item {
+	if item == nil {
+		return nil
+	}
+
+	c := make(Item, len(item))
+	for k, v := range item {
+		c[k] = v
+	}
+
+	return c
+}
@@ -0,0 +1,128 @@
+package state
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestCollectionShould(t *testing.T) {
+	seed := func() []Item {
+		return []Item{
+			{"id": json.Number("1"), "name": "alice"},
+			{"id": json.Number("4"), "name": "bob"},
+		}
+	}
+
+	t.Run("list seeded items in order", func(t *testing.T) {
+		c := newCollection("id", seed())
+
+		if items := c.List(); !reflect.DeepEqual(seed(), items) {
+			t.Errorf(errFmt, seed(), items)
+		}
+	})
+
+	t.Run("generate ids following the largest integer id", func(t *testing.T) {
+		c := newCollection("id", seed())
+
+		item, err := c.Insert(Item{"name": "carol"})
+		if err != nil {
+			t.Fatalf(errFmt, nil, err)
+		}
+
+		if id := c.ID(item); id != "5" {
+			t.Errorf(errFmt, "5", id)
+		}
+	})
+
+	t.Run("reject inserting an item with an existing id", func(t *testing.T) {
+		c := newCollection("id", seed())
+
+		expected := ErrItemExists{ID: "1"}
+		if _, err := c.Insert(Item{"id": "1"}); err != expected {
+			t.Errorf(errFmt, expected, err)
+		}
+	})
+
+	t.Run("replace an item, keeping its id", func(t *testing.T) {
+		c := newCollection("id", seed())
+
+		expected := Item{"id": json.Number("1"), "email": "alice@example.com"}
+		if item, ok := c.Replace("1", Item{"email": "alice@example.com"}); !ok || !reflect.DeepEqual(expected, item) {
+			t.Errorf(errFmt, expected, item)
+		}
+	})
+
+	t.Run("merge fields into an item", func(t *testing.T) {
+		c := newCollection("id", seed())
+
+		expected := Item{"id": json.Number("1"), "name": "alice", "admin": true}
+		if item, ok := c.Update("1", Item{"id": "9", "admin": true}); !ok || !reflect.DeepEqual(expected, item) {
+			t.Errorf(errFmt, expected, item)
+		}
+	})
+
+	t.Run("delete an item", func(t *testing.T) {
+		c := newCollection("id", seed())
+
+		if !c.Delete("1") {
+			t.Errorf(errFmt, true, false)
+		}
+
+		if _, prs := c.Get("1"); prs {
+			t.Errorf(errFmt, false, prs)
+		}
+
+		if c.Delete("1") {
+			t.Errorf(errFmt, false, true)
+		}
+	})
+
+	t.Run("restore the seeded items on reset", func(t *testing.T) {
+		c := newCollection("id", seed())
+		c.Delete("1")
+		c.Insert(Item{"name": "carol"})
+		c.Reset()
+
+		if items := c.List(); !reflect.DeepEqual(seed(), items) {
+			t.Errorf(errFmt, seed(), items)
+		}
+	})
+}
+
+func TestCollectionsShould(t *testing.T) {
+	t.Run("keep the items of a collection declared again", func(t *testing.T) {
+		s := NewCollections()
+		s.Declare("users", "id", nil).Insert(Item{"name": "alice"})
+
+		if items := s.Declare("users", "id", nil).List(); len(items) != 1 {
+			t.Errorf(errFmt, 1, len(items))
+		}
+	})
+
+	t.Run("identify the items of a collection declared again by its new id field", func(t *testing.T) {
+		s := NewCollections()
+		s.Declare("users", "id", []Item{{"id": json.Number("1"), "email": "alice@example.com"}})
+		c := s.Declare("users", "email", nil)
+
+		if _, prs := c.Get("alice@example.com"); !prs {
+			t.Errorf(errFmt, true, prs)
+		}
+
+		if _, prs := c.Get("1"); prs {
+			t.Errorf(errFmt, false, prs)
+		}
+	})
+
+	t.Run("reset a collection declared again to its new seed", func(t *testing.T) {
+		s := NewCollections()
+		s.Declare("users", "id", nil)
+		seed := []Item{{"id": "alice"}}
+		c := s.Declare("users", "id", seed)
+		c.Reset()
+
+		if items := c.List(); !reflect.DeepEqual(seed, items) {
+			t.Errorf(errFmt, seed, items)
+		}
+	})
+}
@@ -0,0 +1,86 @@
+// Package state holds state that is shared between routes and persists across
+// reloads of the route configuration.
+package state
+
+import (
+	"sync"
+)
+
+// Initial is the state of a scenario that has never been transitioned or has
+// been reset.
+const Initial = "started"
+
+// DefaultScenarios is the scenario store shared by every route.
+var DefaultScenarios = NewScenarios()
+
+// Scenarios tracks the current state of each named scenario. A Scenarios is
+// safe for concurrent use.
+type Scenarios struct {
+	mu     sync.RWMutex
+	states map[string]string
+}
+
+// NewScenarios returns an empty scenario store.
+func NewScenarios() *Scenarios {
+	return &Scenarios{states: make(map[string]string)}
+}
+
+// Register adds a scenario in its Initial state, leaving the scenario
+// unchanged if it's already known.
+func (s *Scenarios) Register(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, prs := s.states[name]; !prs {
+		s.states[name] = Initial
+	}
+}
+
+// State returns the current state of a scenario, or Initial if the scenario
+// is unknown.
+func (s *Scenarios) State(name string) string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if state, prs := s.states[name]; prs {
+		return state
+	}
+
+	return Initial
+}
+
+// Set transitions a scenario to a new state.
+func (s *Scenarios) Set(name, state string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.states[name] = state
+}
+
+// States returns the current state of every known scenario.
+func (s *Scenarios) States() map[string]string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	states := make(map[string]string, len(s.states))
+	for name, state := range s.states {
+		states[name] = state
+	}
+
+	return states
+}
+
+// Reset returns a scenario to its Initial state.
+func (s *Scenarios) Reset(name string) {
+	s.Set(name, Initial)
+}
+
+// ResetAll returns every known scenario to its Initial state.
+func (s *Scenarios) ResetAll() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for name := range s.states {
+		s.states[name] = Initial
+	}
+}
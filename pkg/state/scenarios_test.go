@@ -0,0 +1,58 @@
+package state
+
+import (
+	"reflect"
+	"testing"
+)
+
+const (
+	errFmt string = "want %v, got %v"
+)
+
+func TestScenariosShould(t *testing.T) {
+	t.Run("report unknown scenarios in their initial state", func(t *testing.T) {
+		s := NewScenarios()
+
+		if state := s.State("orders"); state != Initial {
+			t.Errorf(errFmt, Initial, state)
+		}
+	})
+
+	t.Run("track transitions of each scenario", func(t *testing.T) {
+		s := NewScenarios()
+		s.Register("orders")
+		s.Register("users")
+		s.Set("orders", "created")
+
+		expected := map[string]string{"orders": "created", "users": Initial}
+		if states := s.States(); !reflect.DeepEqual(expected, states) {
+			t.Errorf(errFmt, expected, states)
+		}
+	})
+
+	t.Run("leave registered scenarios unchanged", func(t *testing.T) {
+		s := NewScenarios()
+		s.Set("orders", "created")
+		s.Register("orders")
+
+		if state := s.State("orders"); state != "created" {
+			t.Errorf(errFmt, "created", state)
+		}
+	})
+
+	t.Run("reset scenarios to their initial state", func(t *testing.T) {
+		s := NewScenarios()
+		s.Set("orders", "created")
+		s.Set("users", "deleted")
+
+		s.Reset("orders")
+		if state := s.State("orders"); state != Initial {
+			t.Errorf(errFmt, Initial, state)
+		}
+
+		s.ResetAll()
+		if state := s.State("users"); state != Initial {
+			t.Errorf(errFmt, Initial, state)
+		}
+	})
+}
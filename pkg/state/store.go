@@ -0,0 +1,109 @@
+package state
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+)
+
+// Default is the store of the state shared by every route.
+var Default = Store{Scenarios: DefaultScenarios, Collections: DefaultCollections}
+
+// Snapshot is a point in time copy of a Store that can be encoded as JSON.
+type Snapshot struct {
+	Scenarios   map[string]string             `json:"scenarios"`
+	Collections map[string]CollectionSnapshot `json:"collections"`
+}
+
+// CollectionSnapshot is a point in time copy of a Collection.
+type CollectionSnapshot struct {
+	IDField string `json:"id_field"`
+	Items   []Item `json:"items"`
+}
+
+// Store groups the scenarios and collections that make up the state shared
+// by routes, so that they can be snapshotted, restored and reset together.
+type Store struct {
+	Scenarios   *Scenarios
+	Collections *Collections
+}
+
+// Snapshot returns a copy of the current state.
+func (s Store) Snapshot() Snapshot {
+	return Snapshot{
+		Scenarios:   s.Scenarios.States(),
+		Collections: s.Collections.snapshot(),
+	}
+}
+
+// Restore merges a snapshot into the current state, setting the state of
+// each scenario and replacing the items of each collection in the snapshot.
+// Scenarios and collections that aren't in the snapshot are left unchanged.
+func (s Store) Restore(snapshot Snapshot) {
+	for name, state := range snapshot.Scenarios {
+		s.Scenarios.Set(name, state)
+	}
+
+	s.Collections.restore(snapshot.Collections)
+}
+
+// Reset returns every scenario to its Initial state and every collection to
+// its seed items.
+func (s Store) Reset() {
+	s.Scenarios.ResetAll()
+	s.Collections.ResetAll()
+}
+
+// Save writes a snapshot of the current state to a file as JSON. The file is
+// replaced atomically so that a failed save never leaves a partial snapshot.
+func (s Store) Save(path string) error {
+	b, err := json.MarshalIndent(s.Snapshot(), "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+// Load restores the state from a snapshot previously written by Save.
+func (s Store) Load(path string) error {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	snapshot, err := DecodeSnapshot(b)
+	if err != nil {
+		return err
+	}
+
+	s.Restore(snapshot)
+	return nil
+}
+
+// DecodeSnapshot decodes a snapshot from JSON, preserving the precision of
+// numbers within collection items.
+func DecodeSnapshot(b []byte) (Snapshot, error) {
+	var snapshot Snapshot
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	err := dec.Decode(&snapshot)
+
+	return snapshot, err
+}
@@ -0,0 +1,95 @@
+package state
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestStoreShould(t *testing.T) {
+	seed := []Item{{"id": json.Number("1"), "name": "alice"}}
+	newStore := func() Store {
+		s := Store{Scenarios: NewScenarios(), Collections: NewCollections()}
+		s.Scenarios.Register("orders")
+		s.Collections.Declare("users", "id", seed)
+
+		return s
+	}
+
+	t.Run("restore a snapshot of its state", func(t *testing.T) {
+		s := newStore()
+		s.Scenarios.Set("orders", "created")
+		s.Collections.Declare("users", "id", seed).Insert(Item{"name": "bob"})
+		snapshot := s.Snapshot()
+
+		restored := newStore()
+		restored.Restore(snapshot)
+
+		if expected, got := snapshot, restored.Snapshot(); !reflect.DeepEqual(expected, got) {
+			t.Errorf(errFmt, expected, got)
+		}
+	})
+
+	t.Run("continue generating ids after a restored snapshot", func(t *testing.T) {
+		restored := newStore()
+		restored.Restore(Snapshot{Collections: map[string]CollectionSnapshot{
+			"users": {IDField: "id", Items: []Item{{"id": json.Number("7")}}},
+		}})
+
+		item, _ := restored.Collections.Declare("users", "id", seed).Insert(Item{})
+		if id := ItemID(item["id"]); id != "8" {
+			t.Errorf(errFmt, "8", id)
+		}
+	})
+
+	t.Run("identify restored items by the id field the collection is declared with", func(t *testing.T) {
+		restored := Store{Scenarios: NewScenarios(), Collections: NewCollections()}
+		restored.Restore(Snapshot{Collections: map[string]CollectionSnapshot{
+			"users": {IDField: "id", Items: []Item{{"id": json.Number("1"), "name": "alice"}}},
+		}})
+
+		if _, prs := restored.Collections.Declare("users", "name", nil).Get("alice"); !prs {
+			t.Errorf(errFmt, true, prs)
+		}
+	})
+
+	t.Run("reset scenarios and collections", func(t *testing.T) {
+		s := newStore()
+		s.Scenarios.Set("orders", "created")
+		s.Collections.Declare("users", "id", seed).Delete("1")
+		s.Reset()
+
+		expected := newStore().Snapshot()
+		if got := s.Snapshot(); !reflect.DeepEqual(expected, got) {
+			t.Errorf(errFmt, expected, got)
+		}
+	})
+
+	t.Run("save and load its state from a file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "state.json")
+
+		s := newStore()
+		s.Scenarios.Set("orders", "created")
+		if err := s.Save(path); err != nil {
+			t.Fatalf(errFmt, nil, err)
+		}
+
+		loaded := Store{Scenarios: NewScenarios(), Collections: NewCollections()}
+		if err := loaded.Load(path); err != nil {
+			t.Fatalf(errFmt, nil, err)
+		}
+
+		if expected, got := s.Snapshot(), loaded.Snapshot(); !reflect.DeepEqual(expected, got) {
+			t.Errorf(errFmt, expected, got)
+		}
+	})
+
+	t.Run("return an error loading a missing file", func(t *testing.T) {
+		err := newStore().Load(filepath.Join(t.TempDir(), "state.json"))
+		if !os.IsNotExist(err) {
+			t.Errorf(errFmt, os.ErrNotExist, err)
+		}
+	})
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/ncatelli/mockserver/pkg/router"
+)
+
+// printRoutes writes a table describing each route to w in the order they're
+// matched, followed by any fallback route. Each route includes its priority,
+// the matchers a request must satisfy to be routed to it, its middleware
+// chain and the weight and status of each of its handlers. Groups are listed
+// ahead of their child routes, with the location of each child including the
+// group's path prefix.
+func printRoutes(w io.Writer, routes []*router.Route) {
+	ordered := make([]*router.Route, 0, len(routes))
+	var fallbacks []*router.Route
+	for _, r := range router.Ordered(routes) {
+		if r.Fallback {
+			fallbacks = append(fallbacks, r)
+		} else {
+			ordered = append(ordered, r)
+		}
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "PRIORITY\tMETHOD\tLOCATION\tHEADERS\tQUERY\tBODY\tMIDDLEWARE\tHANDLERS\tSOURCE")
+	printRows(tw, append(ordered, fallbacks...), "")
+	tw.Flush()
+}
+
+// printRows writes a table row for each route, descending into groups. Each
+// route's path is joined to the prefix of its enclosing groups.
+func printRows(w io.Writer, routes []*router.Route, prefix string) {
+	for _, r := range routes {
+		source := r.Source
+		if r.Line > 0 {
+			source = fmt.Sprintf("%s:%d", source, r.Line)
+		}
+
+		body := ""
+		if r.Body != nil {
+			body = r.Body.Describe()
+		}
+
+		handlers := formatHandlers(r.Handlers)
+		if r.IsGroup() {
+			handlers = fmt.Sprintf("group(%d)", len(r.Routes))
+		} else if r.Resource != nil {
+			handlers = fmt.Sprintf("resource(%s)", r.Resource.Name)
+		} else if strings.EqualFold(r.Selection, router.SelectionSequence) {
+			end := router.SequenceEndLast
+			if len(r.SequenceEnd) > 0 {
+				end = strings.ToLower(r.SequenceEnd)
+			}
+
+			handlers = fmt.Sprintf("%s(%s):%s", router.SelectionSequence, end, handlers)
+		}
+
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			r.Priority,
+			orNone(r.Method.String()),
+			formatLocation(r, prefix),
+			formatHeaders(r),
+			formatMatchers(r.QueryParams),
+			orNone(body),
+			orNone(strings.Join(r.MiddlewareChain(), " > ")),
+			handlers,
+			orNone(source),
+		)
+
+		if r.IsGroup() {
+			printRows(w, router.Ordered(r.Routes), prefix+r.PathPrefix)
+		}
+	}
+}
+
+// logRoutes logs the table of routes being served.
+func logRoutes(routes []*router.Route) {
+	var table bytes.Buffer
+	printRoutes(&table, routes)
+	log.Printf("serving %d routes:\n%s", len(router.Flatten(routes)), table.String())
+}
+
+// formatLocation formats a route's scheme, host and path matchers as a URL,
+// with the path joined to the prefix of any enclosing groups. A group's
+// location is its path prefix followed by a wildcard.
+func formatLocation(r *router.Route, prefix string) string {
+	path := prefix + r.Path
+	if r.IsGroup() {
+		path = prefix + r.PathPrefix + "*"
+	}
+
+	if r.Fallback {
+		return "(fallback)"
+	} else if len(r.Host) == 0 && len(r.Scheme) == 0 {
+		return path
+	}
+
+	scheme := "*"
+	if len(r.Scheme) > 0 {
+		scheme = strings.ToLower(r.Scheme)
+	}
+
+	host := "*"
+	if len(r.Host) > 0 {
+		host = r.Host
+	}
+
+	return fmt.Sprintf("%s://%s%s", scheme, host, path)
+}
+
+// formatHeaders formats a route's header and cookie matchers, prefixing
+// cookie matchers with "cookie:".
+func formatHeaders(r *router.Route) string {
+	headers := formatMatchers(r.RequestHeaders)
+	if len(r.Cookies) == 0 {
+		return headers
+	}
+
+	cookies := make([]string, 0, len(r.Cookies))
+	for k, m := range r.Cookies {
+		cookies = append(cookies, "cookie:"+m.Describe(k))
+	}
+	sort.Strings(cookies)
+
+	if headers == "-" {
+		return strings.Join(cookies, ",")
+	}
+
+	return headers + "," + strings.Join(cookies, ",")
+}
+
+// formatMatchers formats a set of matchers as a sorted, comma separated
+// list.
+func formatMatchers(matchers map[string]router.Matcher) string {
+	descriptions := make([]string, 0, len(matchers))
+	for k, m := range matchers {
+		descriptions = append(descriptions, m.Describe(k))
+	}
+	sort.Strings(descriptions)
+
+	return orNone(strings.Join(descriptions, ","))
+}
+
+// formatHandlers formats the weight and response status of each handler.
+// Handlers selected by a when condition are marked in place of their weight,
+// the weight of handlers requiring a scenario state is omitted, and handlers
+// belonging to a scenario are followed by the scenario, the state they
+// require and the state they transition to, e.g. [orders:started>created].
+func formatHandlers(handlers []router.Handler) string {
+	descriptions := make([]string, 0, len(handlers))
+	for _, h := range handlers {
+		description := fmt.Sprintf("%d", h.ResponseStatus)
+		if h.When != nil {
+			description += "(when)"
+		} else if len(h.RequiredState) == 0 {
+			description += fmt.Sprintf("(w=%d)", h.Weight)
+		}
+
+		if len(h.Scenario) > 0 {
+			description += fmt.Sprintf("[%s:%s", h.Scenario, h.RequiredState)
+			if len(h.NewState) > 0 {
+				description += ">" + h.NewState
+			}
+			description += "]"
+		}
+
+		descriptions = append(descriptions, description)
+	}
+
+	return orNone(strings.Join(descriptions, ","))
+}
+
+// orNone substitutes a placeholder for empty table cells so columns remain
+// aligned.
+func orNone(s string) string {
+	if len(s) == 0 {
+		return "-"
+	}
+
+	return s
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/ncatelli/mockserver/pkg/router"
+)
+
+func TestPrintRoutesShould(t *testing.T) {
+	t.Run("describe each route's matchers, body, middleware and handlers", func(t *testing.T) {
+		routes := []*router.Route{
+			{
+				Path:           "/test",
+				Method:         router.Methods{"GET"},
+				RequestHeaders: map[string]router.Matcher{"b": {Value: "2"}, "a": {Value: "1"}},
+				Body:           &router.BodyMatcher{JSONPath: map[string]router.Matcher{"$.type": {Value: "order"}}},
+				Middleware:     map[string]map[string]string{"logging": {}, "latency": {}},
+				Handlers: []router.Handler{
+					{Weight: 2, ResponseStatus: 200},
+					{Weight: 1, ResponseStatus: 500},
+				},
+				Source: "mocks.yaml",
+				Line:   3,
+			},
+		}
+
+		var out bytes.Buffer
+		printRoutes(&out, routes)
+
+		lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+		if len(lines) != 2 {
+			t.Fatalf(errFmt, 2, len(lines))
+		}
+
+		expected := []string{"0", "GET", "/test", "a=1,b=2", "-", "$.type=order", "latency", ">", "logging", "200(w=2),500(w=1)", "mocks.yaml:3"}
+		if fields := strings.Fields(lines[1]); strings.Join(fields, " ") != strings.Join(expected, " ") {
+			t.Errorf(errFmt, expected, fields)
+		}
+	})
+}
+
+func TestPrintRoutesSequenceShould(t *testing.T) {
+	t.Run("describe a sequence and how it ends", func(t *testing.T) {
+		routes := []*router.Route{
+			{
+				Path:      "/retry",
+				Method:    router.Methods{"GET"},
+				Selection: router.SelectionSequence,
+				Handlers: []router.Handler{
+					{Weight: 2, ResponseStatus: 503},
+					{Weight: 1, ResponseStatus: 200},
+				},
+			},
+		}
+
+		var out bytes.Buffer
+		printRoutes(&out, routes)
+
+		lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+		expected := "sequence(last):503(w=2),200(w=1)"
+		if handlers := strings.Fields(lines[1])[7]; handlers != expected {
+			t.Errorf(errFmt, expected, handlers)
+		}
+	})
+}
+
+func TestFormatHandlersShould(t *testing.T) {
+	t.Run("describe conditions and scenario transitions", func(t *testing.T) {
+		handlers := []router.Handler{
+			{Weight: 1, ResponseStatus: 201, Scenario: "orders", NewState: "created"},
+			{ResponseStatus: 200, Scenario: "orders", RequiredState: "created"},
+			{ResponseStatus: 404, When: &router.Condition{Expression: "true"}},
+		}
+
+		expected := "201(w=1)[orders:>created],200[orders:created],404(when)"
+		if description := formatHandlers(handlers); description != expected {
+			t.Errorf(errFmt, expected, description)
+		}
+	})
+}
+
+func TestPrintRoutesOrderShould(t *testing.T) {
+	t.Run("list routes in match order followed by the fallback", func(t *testing.T) {
+		routes := []*router.Route{
+			{Fallback: true},
+			{Path: "/low", Method: router.Methods{"GET"}},
+			{Path: "/high", Method: router.Methods{"GET"}, Priority: 5},
+		}
+
+		var out bytes.Buffer
+		printRoutes(&out, routes)
+
+		var locations []string
+		for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n")[1:] {
+			locations = append(locations, strings.Fields(line)[2])
+		}
+
+		expected := []string{"/high", "/low", "(fallback)"}
+		if strings.Join(expected, " ") != strings.Join(locations, " ") {
+			t.Errorf(errFmt, expected, locations)
+		}
+	})
+
+	t.Run("list child routes in match order after their group", func(t *testing.T) {
+		routes := []*router.Route{
+			{PathPrefix: "/v1", Routes: []*router.Route{
+				{Path: "/low", Method: router.Methods{"GET"}},
+				{Path: "/high", Method: router.Methods{"GET"}, Priority: 5},
+			}},
+			{Path: "/health", Method: router.Methods{"GET"}},
+		}
+
+		var out bytes.Buffer
+		printRoutes(&out, routes)
+
+		var locations []string
+		for _, line := range strings.Split(strings.TrimSpace(out.String()), "\n")[1:] {
+			locations = append(locations, strings.Fields(line)[2])
+		}
+
+		expected := []string{"/v1*", "/v1/high", "/v1/low", "/health"}
+		if strings.Join(expected, " ") != strings.Join(locations, " ") {
+			t.Errorf(errFmt, expected, locations)
+		}
+	})
+}
+
+func TestFormatLocationShould(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		route    router.Route
+		prefix   string
+		expected string
+	}{
+		{name: "return the path alone", route: router.Route{Path: "/test"}, expected: "/test"},
+		{name: "prefix the scheme and host", route: router.Route{Path: "/test", Host: "{tenant}.example.com", Scheme: "HTTPS"}, expected: "https://{tenant}.example.com/test"},
+		{name: "substitute wildcards for unset fields", route: router.Route{Path: "/test", Scheme: "http"}, expected: "http://*/test"},
+		{name: "join the path to the group prefix", route: router.Route{Path: "/users"}, prefix: "/v1", expected: "/v1/users"},
+		{name: "describe a group by its prefix", route: router.Route{PathPrefix: "/v2", Routes: []*router.Route{{}}}, prefix: "/api", expected: "/api/v2*"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if location := formatLocation(&tc.route, tc.prefix); location != tc.expected {
+				t.Errorf(errFmt, tc.expected, location)
+			}
+		})
+	}
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"net/http"
+	"sync/atomic"
+)
+
+// boxedHandler wraps an http.Handler so that handlers of differing concrete
+// types can be stored in the same atomic.Value.
+type boxedHandler struct {
+	http.Handler
+}
+
+// swapHandler implements http.Handler, forwarding all requests to an
+// underlying handler that can be atomically replaced while serving.
+type swapHandler struct {
+	current atomic.Value
+}
+
+// Swap atomically replaces the handler that requests are forwarded to.
+func (s *swapHandler) Swap(h http.Handler) {
+	s.current.Store(boxedHandler{h})
+}
+
+// ServeHTTP forwards the request to the current handler, responding with a
+// 503 if no handler has been set.
+func (s *swapHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h, ok := s.current.Load().(boxedHandler)
+	if !ok {
+		http.Error(w, "", http.StatusServiceUnavailable)
+		return
+	}
+
+	h.ServeHTTP(w, r)
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func staticHandler(body string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, body)
+	}
+}
+
+func TestSwapHandlerShould(t *testing.T) {
+	t.Run("return a 503 when no handler has been set", func(t *testing.T) {
+		err := handlerTest("GET", "/", nil, http.StatusServiceUnavailable, "", (&swapHandler{}).ServeHTTP)
+		if err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("forward requests to the most recently swapped handler", func(t *testing.T) {
+		s := &swapHandler{}
+		s.Swap(staticHandler("first"))
+		s.Swap(staticHandler("second"))
+
+		err := handlerTest("GET", "/", nil, http.StatusOK, "second", s.ServeHTTP)
+		if err != nil {
+			t.Error(err)
+		}
+	})
+
+	t.Run("accept handlers of differing types", func(t *testing.T) {
+		s := &swapHandler{}
+		s.Swap(staticHandler("first"))
+		s.Swap(http.NewServeMux())
+
+		err := handlerTest("GET", "/", nil, http.StatusNotFound, "", s.ServeHTTP)
+		if err != nil {
+			t.Error(err)
+		}
+	})
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/ncatelli/mockserver/pkg/config"
+	"github.com/ncatelli/mockserver/pkg/router"
+)
+
+// validateConfig loads and builds the route configuration without serving it,
+// writing every problem found to w. True is returned if the configuration is
+// valid.
+func validateConfig(c *config.Config, w io.Writer) bool {
+	routes, _, err := buildRouterFromConfig(c)
+	if err != nil {
+		// report each validation problem on its own line.
+		var problems router.ErrValidation
+		if errors.As(err, &problems) {
+			for _, p := range problems {
+				fmt.Fprintln(w, p)
+			}
+		} else {
+			fmt.Fprintln(w, err)
+		}
+
+		return false
+	}
+
+	for _, route := range routes {
+		route.Close()
+	}
+
+	fmt.Fprintf(w, "%s: %d routes ok\n", c.Source(), len(router.Flatten(routes)))
+	return true
+}
+
+// validate checks the route configuration at each passed path, or the
+// configured source if no paths are passed, returning the exit status for
+// the validate command.
+func validate(c config.Config, paths []string, w io.Writer) int {
+	if len(paths) == 0 {
+		if validateConfig(&c, w) {
+			return 0
+		}
+
+		return 1
+	}
+
+	status := 0
+	for _, p := range paths {
+		pc := c
+		pc.ConfigPath = p
+		if !validateConfig(&pc, w) {
+			status = 1
+		}
+	}
+
+	return status
+}
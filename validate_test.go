@@ -0,0 +1,68 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/ncatelli/mockserver/pkg/config"
+)
+
+func TestValidateShould(t *testing.T) {
+	validConfig := `
+- path: "/test"
+  method: GET
+  handlers:
+  - weight: 1
+    static_response: 'ok'
+    response_status: 200
+`
+
+	t.Run("exit zero for a valid configuration", func(t *testing.T) {
+		c := config.Config{ConfigPath: writeConfig(t, validConfig)}
+
+		var out bytes.Buffer
+		if status := validate(c, nil, &out); status != 0 {
+			t.Errorf(errFmt, 0, status)
+		}
+	})
+
+	t.Run("report every problem and exit non-zero for an invalid configuration", func(t *testing.T) {
+		path := writeConfig(t, `
+- path: "/test"
+  method: GET
+  handlers:
+  - weight: 1
+    response_status: 0
+- path: "/missing-method"
+  handlers:
+  - weight: 1
+    response_status: 200
+`)
+
+		var out bytes.Buffer
+		if status := validate(config.Config{}, []string{path}, &out); status != 1 {
+			t.Errorf(errFmt, 1, status)
+		}
+
+		lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+		if len(lines) != 2 {
+			t.Fatalf(errFmt, 2, lines)
+		}
+
+		for i, expected := range []string{path + ":2:", path + ":7:"} {
+			if !strings.HasPrefix(lines[i], expected) {
+				t.Errorf(errFmt, expected, lines[i])
+			}
+		}
+	})
+
+	t.Run("exit non-zero if any of the passed paths is invalid", func(t *testing.T) {
+		paths := []string{writeConfig(t, validConfig), writeConfig(t, ";189na--ac")}
+
+		var out bytes.Buffer
+		if status := validate(config.Config{}, paths, &out); status != 1 {
+			t.Errorf(errFmt, 1, status)
+		}
+	})
+}
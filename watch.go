@@ -0,0 +1,185 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/ncatelli/mockserver/pkg/config"
+	"github.com/ncatelli/mockserver/pkg/router"
+)
+
+// watchDebounce is the window in which multiple filesystem events are
+// coalesced into a single change notification. Editors commonly emit several
+// events for a single save.
+const watchDebounce = 100 * time.Millisecond
+
+// watchedPaths returns the config path along with every included file and
+// response_path referenced by the routes.
+func watchedPaths(c *config.Config, routes []*router.Route) []string {
+	paths := []string{c.ConfigPath}
+	sources := make(map[string]bool)
+
+	for _, route := range router.Flatten(routes) {
+		// routes included from a URL are not watched.
+		if len(route.Source) > 0 && !strings.Contains(route.Source, "://") && !sources[route.Source] {
+			sources[route.Source] = true
+			paths = append(paths, route.Source)
+		}
+
+		for _, handler := range route.Handlers {
+			if len(handler.ResponsePath) > 0 {
+				paths = append(paths, handler.ResponsePath)
+			}
+		}
+	}
+
+	return paths
+}
+
+// fileWatcher watches a set of paths for changes. A single fileWatcher is
+// kept for the lifetime of the server and its paths are replaced after each
+// reload, so that changes made while a reload is in progress are still
+// detected.
+type fileWatcher struct {
+	watcher    *fsnotify.Watcher
+	extensions []string
+
+	mu       sync.Mutex
+	files    map[string]bool
+	patterns []string
+	dirs     map[string]bool
+}
+
+// newFileWatcher returns a fileWatcher watching no paths. extensions are the
+// file extensions watched within any directory passed to Watch.
+func newFileWatcher(extensions []string) (*fileWatcher, error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+
+	return &fileWatcher{
+		watcher:    watcher,
+		extensions: extensions,
+		files:      make(map[string]bool),
+		dirs:       make(map[string]bool),
+	}, nil
+}
+
+// Watch replaces the watched paths. A path may be a file, a directory, in
+// which case any file within it with one of the watcher's extensions is
+// watched, or a glob pattern. The parent directory of each file is watched
+// rather than the file itself so that editors that save by replacing the
+// file, and files newly matching a directory or pattern, are still detected.
+// Every path is being watched once Watch returns.
+func (w *fileWatcher) Watch(paths []string) error {
+	files := make(map[string]bool)
+	patterns := make([]string, 0)
+	dirs := make(map[string]bool)
+	for _, p := range paths {
+		abs, err := filepath.Abs(p)
+		if err != nil {
+			return err
+		}
+
+		if info, err := os.Stat(abs); err == nil && info.IsDir() {
+			for _, ext := range w.extensions {
+				patterns = append(patterns, filepath.Join(abs, "*"+ext))
+			}
+			dirs[abs] = true
+		} else if strings.ContainsAny(abs, "*?[") {
+			patterns = append(patterns, abs)
+
+			// the directory portion of a pattern may itself be a pattern.
+			matches, err := filepath.Glob(filepath.Dir(abs))
+			if err != nil {
+				return err
+			}
+
+			for _, m := range matches {
+				dirs[m] = true
+			}
+		} else {
+			files[abs] = true
+			dirs[filepath.Dir(abs)] = true
+		}
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	for dir := range dirs {
+		if !w.dirs[dir] {
+			if err := w.watcher.Add(dir); err != nil {
+				return err
+			}
+		}
+	}
+
+	// directories that have since been removed can't be unwatched, so any
+	// error doing so is ignored.
+	for dir := range w.dirs {
+		if !dirs[dir] {
+			w.watcher.Remove(dir)
+		}
+	}
+
+	w.files, w.patterns, w.dirs = files, patterns, dirs
+	return nil
+}
+
+// watched returns true if the named file is one of the watched paths.
+func (w *fileWatcher) watched(name string) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.files[name] {
+		return true
+	}
+
+	for _, p := range w.patterns {
+		if ok, _ := filepath.Match(p, name); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Run sends on changed whenever any of the watched paths are written,
+// created, removed or renamed. Events continue to be received while a change
+// notification is pending, so that any change made before the notification
+// is received is coalesced into it, and any made after it is received causes
+// another. Run blocks until done is closed.
+func (w *fileWatcher) Run(done <-chan struct{}, changed chan<- struct{}) error {
+	// debounce is nil, and therefore blocks, until a relevant event has been
+	// received. notify is likewise nil until a change is pending.
+	var debounce <-chan time.Time
+	var notify chan<- struct{}
+	for {
+		select {
+		case <-done:
+			return nil
+		case err := <-w.watcher.Errors:
+			return err
+		case event := <-w.watcher.Events:
+			if w.watched(filepath.Clean(event.Name)) {
+				debounce = time.After(watchDebounce)
+			}
+		case <-debounce:
+			debounce = nil
+			notify = changed
+		case notify <- struct{}{}:
+			notify = nil
+		}
+	}
+}
+
+// Close stops watching every path.
+func (w *fileWatcher) Close() error {
+	return w.watcher.Close()
+}
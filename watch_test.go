@@ -0,0 +1,190 @@
+package main
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/ncatelli/mockserver/pkg/config"
+	"github.com/ncatelli/mockserver/pkg/router"
+)
+
+func TestWatchedPathsShould(t *testing.T) {
+	t.Run("include the config path, included files and every response path", func(t *testing.T) {
+		c := &config.Config{ConfigPath: "mocks.yaml"}
+		routes := []*router.Route{
+			{
+				Handlers: []router.Handler{
+					{ResponsePath: "a.txt"},
+					{StaticResponse: "ok"},
+				},
+			},
+			{
+				Source: "shared.yaml",
+				Handlers: []router.Handler{
+					{ResponsePath: "b.txt"},
+				},
+			},
+			{
+				Source: "http://127.0.0.1/remote.yaml",
+			},
+		}
+
+		expected := []string{"mocks.yaml", "a.txt", "shared.yaml", "b.txt"}
+		if paths := watchedPaths(c, routes); !reflect.DeepEqual(expected, paths) {
+			t.Errorf(errFmt, expected, paths)
+		}
+	})
+}
+
+// startFileWatcher starts a fileWatcher watching paths, returning the channel
+// it sends change notifications on. The watcher is stopped when the test
+// completes.
+func startFileWatcher(t *testing.T, paths []string, extensions []string) (*fileWatcher, <-chan struct{}) {
+	w, err := newFileWatcher(extensions)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := w.Watch(paths); err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan struct{})
+	changed := make(chan struct{})
+	go w.Run(done, changed)
+	t.Cleanup(func() {
+		close(done)
+		w.Close()
+	})
+
+	return w, changed
+}
+
+func TestFileWatcherShould(t *testing.T) {
+	t.Run("notify when a watched file is written", func(t *testing.T) {
+		dir := t.TempDir()
+		path := filepath.Join(dir, "mocks.yaml")
+		if err := ioutil.WriteFile(path, []byte("[]"), 0600); err != nil {
+			t.Fatal(err)
+		}
+
+		_, changed := startFileWatcher(t, []string{path}, nil)
+		if err := ioutil.WriteFile(path, []byte("[{}]"), 0600); err != nil {
+			t.Fatal(err)
+		}
+
+		select {
+		case <-changed:
+		case <-time.After(time.Second):
+			t.Errorf(errFmt, "a change notification", nil)
+		}
+	})
+
+	t.Run("notify when a file is added to a watched directory", func(t *testing.T) {
+		dir := t.TempDir()
+		_, changed := startFileWatcher(t, []string{dir}, []string{".yaml"})
+		if err := ioutil.WriteFile(filepath.Join(dir, "mocks.yaml"), []byte("[]"), 0600); err != nil {
+			t.Fatal(err)
+		}
+
+		select {
+		case <-changed:
+		case <-time.After(time.Second):
+			t.Errorf(errFmt, "a change notification", nil)
+		}
+	})
+
+	t.Run("notify when a file matching a watched pattern is written", func(t *testing.T) {
+		dir := t.TempDir()
+		_, changed := startFileWatcher(t, []string{filepath.Join(dir, "*.mock.yaml")}, nil)
+		if err := ioutil.WriteFile(filepath.Join(dir, "team.mock.yaml"), []byte("[]"), 0600); err != nil {
+			t.Fatal(err)
+		}
+
+		select {
+		case <-changed:
+		case <-time.After(time.Second):
+			t.Errorf(errFmt, "a change notification", nil)
+		}
+	})
+
+	t.Run("ignore changes to unwatched files in the same directory", func(t *testing.T) {
+		dir := t.TempDir()
+		path := filepath.Join(dir, "mocks.yaml")
+		if err := ioutil.WriteFile(path, []byte("[]"), 0600); err != nil {
+			t.Fatal(err)
+		}
+
+		_, changed := startFileWatcher(t, []string{path}, nil)
+		if err := ioutil.WriteFile(filepath.Join(dir, "other.yaml"), []byte("[]"), 0600); err != nil {
+			t.Fatal(err)
+		}
+
+		select {
+		case <-changed:
+			t.Errorf(errFmt, "no change notification", "a change notification")
+		case <-time.After(2 * watchDebounce):
+		}
+	})
+
+	t.Run("notify of changes made before a previous notification is handled", func(t *testing.T) {
+		dir := t.TempDir()
+		path := filepath.Join(dir, "mocks.yaml")
+		if err := ioutil.WriteFile(path, []byte("[]"), 0600); err != nil {
+			t.Fatal(err)
+		}
+
+		_, changed := startFileWatcher(t, []string{path}, nil)
+		if err := ioutil.WriteFile(path, []byte("[{}]"), 0600); err != nil {
+			t.Fatal(err)
+		}
+
+		select {
+		case <-changed:
+		case <-time.After(time.Second):
+			t.Fatalf(errFmt, "a change notification", nil)
+		}
+
+		// written while the first change is still being handled, such as
+		// while a reload is in progress.
+		if err := ioutil.WriteFile(path, []byte("[{}, {}]"), 0600); err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(2 * watchDebounce)
+
+		select {
+		case <-changed:
+		case <-time.After(time.Second):
+			t.Errorf(errFmt, "a change notification", nil)
+		}
+	})
+
+	t.Run("notify when a newly watched file is written", func(t *testing.T) {
+		dir := t.TempDir()
+		path := filepath.Join(dir, "mocks.yaml")
+		added := filepath.Join(t.TempDir(), "shared.yaml")
+		for _, p := range []string{path, added} {
+			if err := ioutil.WriteFile(p, []byte("[]"), 0600); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		w, changed := startFileWatcher(t, []string{path}, nil)
+		if err := w.Watch([]string{path, added}); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := ioutil.WriteFile(added, []byte("[{}]"), 0600); err != nil {
+			t.Fatal(err)
+		}
+
+		select {
+		case <-changed:
+		case <-time.After(time.Second):
+			t.Errorf(errFmt, "a change notification", nil)
+		}
+	})
+}